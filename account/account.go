@@ -3,68 +3,150 @@
 // *                                                                  *
 // * 2020-03-13 First Version, JR                                     *
 // * 2020-11-17 Adds Blocked merchant feature                         *
+// * 2026-10-19 Adds per-account merchant allow/deny lists, JR        *
+// * 2026-10-19 Adds configurable rules and amount velocity check, JR *
+// * 2026-10-19 Adds suspected doubled transaction check, JR          *
+// * 2026-10-19 Applies transactions with only flagged violations, JR *
+// * 2026-10-19 Adds read-only transaction evaluation, JR             *
+// * 2026-10-19 Adds invalid (negative) amount check, JR              *
+// * 2026-10-19 Makes Account safe for concurrent use, JR             *
+// * 2026-10-19 Adds optional transaction id, JR                      *
+// * 2026-10-19 Adds tracing spans of the transaction checks, JR      *
+// * 2026-10-19 Adds debug logs of the transaction checks, JR         *
+// * 2026-10-19 Keeps the outcome of the authorized transactions, JR  *
+// * 2026-10-19 Keeps the declined attempts and locks the card, JR    *
+// * 2026-10-19 Replaces the active flag by the card lifecycle, JR    *
+// * 2026-10-19 Rejects accounts with a negative limit, JR            *
 // * This package holds all bussiness logic related with an account.  *
 // *                                                                  *
 // * Usage:                                                           *
 // * acn: = account.Init(active, limit)                               *
 // * acn.ApplyTransaction(transation)                                 *
+// * acn.ApplyTransactionContext(ctx, transation)                     *
+// * acn.ApplyUpdate(update)                                          *
+// * acn.Evaluate(transaction, rules)                                 *
 // ********************************************************************
 
 package account
 
 import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
 	"math"
+	"sync"
 	"time"
 )
 
-// Number of minutes - time window for transation checks.
+// Number of minutes - default time window for transation checks.
 const maxTimeDiff = 2
 
-// Max number of transactions allowed in $maxTimeDiff (minutes);
+// Default max number of transactions allowed in $maxTimeDiff (minutes);
 const maxTransactions = 3
 
+// Logger of the accounts without one, discarding every record.
+var discard = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
 // Violations - Account violation's codes and its meaning.
 var Violations = map[int]string{
-	0: "account-not-initialized",
-	1: "card-not-active",
-	2: "account-already-initialized",
-	3: "insufficient-limit",
-	4: "doubled-transaction",
-	5: "high-frequency-small-interval",
-	6: "blocked-merchant",
+	0:  "account-not-initialized",
+	1:  "card-not-active",
+	2:  "account-already-initialized",
+	3:  "insufficient-limit",
+	4:  "doubled-transaction",
+	5:  "high-frequency-small-interval",
+	6:  "blocked-merchant",
+	7:  "account-blocked-merchant",
+	8:  "merchant-not-allowed",
+	9:  "high-amount-small-interval",
+	10: "suspected-doubled-transaction",
+	11: "invalid-amount",
+	12: "too-many-declines",
+	13: "card-blocked",
+	14: "card-reported-lost",
+	15: "card-reported-stolen",
+	16: "card-expired",
+	17: "card-closed",
+	18: "invalid-card-transition",
+	19: "invalid-card-expiry",
+}
+
+// Code - Returns the violation code for a violation name.
+func Code(name string) (int, bool) {
+	for code, val := range Violations {
+		if val == name {
+			return code, true
+		}
+	}
+
+	return -1, false
 }
 
 var blockedlist = []string{
 	"Burger King",
 }
 
-// Account - stores limit, state and authorized transactions,
-// for working account.
+// Account - stores limit, state and authorized transactions, with their,
+// outcome by index, and the declined attempts apart, for working account.
+// It is safe for concurrent use, every method locks,
+// the account while it runs, so a transaction is checked and applied,
+// atomically and concurrent transactions never overspend the limit.
 type Account struct {
-	active       bool
+	mu           sync.Mutex
+	card         string
+	expiry       string
 	limit        int
 	transactions []*Transaction
+	outcomes     []outcome
+	declines     []decline
+	unlocked     int
+	locked       bool
+	allowlist    []string
+	denylist     []string
+	rules        *Rules
+	logger       *slog.Logger
 }
 
-// Transaction - represents transaction fields gotten from json input.
+// Transaction - represents transaction fields gotten from json input,
+// the id is optional and identifies retries of the same transaction.
 type Transaction struct {
+	ID       string `json:"id,omitempty"`
 	Merchant string `json:"merchant"`
 	Amount   int    `json:"amount"`
 	Time     string `json:"time"`
 }
 
-// Init - Initializes an account, and return a violation code,
-// if account is already initialized.
+// Update - represents account fields that can be changed after creation,
+// a nil list keeps the current one, an empty list clears it, a nil lock,
+// keeps the card locked or unlocked, and an empty card state or expiry,
+// keeps the current one.
+type Update struct {
+	AllowedMerchants []string `json:"allowed-merchants,omitempty"`
+	DeniedMerchants  []string `json:"denied-merchants,omitempty"`
+	Locked           *bool    `json:"card-locked,omitempty"`
+	CardState        string   `json:"card-state,omitempty"`
+	CardExpiry       string   `json:"card-expiry,omitempty"`
+}
+
+// Init - Initializes an account, with an active or inactive card, and,
+// return a violation code, if account is already initialized or the,
+// limit is negative.
 func (acn *Account) Init(a bool, l int) (*Account, int) {
 	// If account is already initialized, we return same account,
 	// and violation code.
 	if acn.Initialized() {
 		return acn, 2
 	}
+	// A negative limit could never be spent, the account is not created.
+	if l < 0 {
+		return acn, 11
+	}
 
 	// Otherwise we prepare a new account.
 	account := &Account{
-		active:       a,
+		card:         cardState(a),
 		limit:        l,
 		transactions: []*Transaction{},
 	}
@@ -74,12 +156,62 @@ func (acn *Account) Init(a bool, l int) (*Account, int) {
 
 // Limit - Returns current limit from account.
 func (acn *Account) Limit() int {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
 	return acn.limit
 }
 
-// Active - Returns if account is active or not.
+// Active - Returns if account card is active or not.
 func (acn *Account) Active() bool {
-	return acn.active
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.card == CardActive
+}
+
+// Rules - Returns the rules the account checks transactions with,
+// rules are shared, so they must not be changed once set.
+func (acn *Account) Rules() *Rules {
+	if acn == nil {
+		return DefaultRules()
+	}
+
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.currentRules()
+}
+
+// SetRules - Changes the rules the account checks transactions with,
+// nil restores the default ones.
+func (acn *Account) SetRules(r *Rules) {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	acn.rules = r
+}
+
+// SetLogger - Changes the logger of the transaction checks debug records,
+// nil discards them.
+func (acn *Account) SetLogger(l *slog.Logger) {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	acn.logger = l
+}
+
+// currentLogger - Returns the account logger, the lock must be held.
+func (acn *Account) currentLogger() *slog.Logger {
+	if acn == nil || acn.logger == nil {
+		return discard
+	}
+
+	return acn.logger
+}
+
+// currentRules - Returns the account rules, the lock must be held.
+func (acn *Account) currentRules() *Rules {
+	if acn.rules == nil {
+		return DefaultRules()
+	}
+
+	return acn.rules
 }
 
 // Initialized - returns if the accoun is wheather or not initialized.
@@ -94,57 +226,176 @@ func (acn *Account) Initialized() bool {
 // ApplyTransaction - updates the account's limit if no violations found,
 // and registert the transation in the account's history transactions.
 // otherwise returns an integer array with violation codes found.
+// Violations with an action other than decline are returned as well,
+// but the transaction is still applied.
 func (acn *Account) ApplyTransaction(tsn *Transaction) []int {
+	return acn.ApplyTransactionContext(context.Background(), tsn)
+}
+
+// ApplyTransactionContext - ApplyTransaction, with each check run in its,
+// own span, child of the span in the context if it is recording.
+func (acn *Account) ApplyTransactionContext(ctx context.Context, tsn *Transaction) []int {
+	if !acn.Initialized() {
+		return acn.evaluate(ctx, tsn, DefaultRules())
+	}
+
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	rules := acn.currentRules()
+	violations := acn.evaluate(ctx, tsn, rules)
+
+	// If no violations found, or all of them are flags, apply the transaction,
+	// and register in history, otherwise register the declined attempt.
+	decision, score := rules.Decide(violations)
+	if decision != Declined {
+		acn.limit = acn.limit - tsn.Amount
+		// The violations returned may be changed by the caller.
+		acn.registryTransaction(tsn, outcome{decision: decision, violations: append([]int{}, violations...)})
+	} else {
+		acn.registryDecline(tsn, violations, rules)
+	}
+	acn.currentLogger().LogAttrs(ctx, slog.LevelDebug, "transaction decided",
+		slog.String("merchant", tsn.Merchant),
+		slog.Int("amount", tsn.Amount),
+		slog.Any("violations", violations),
+		slog.String("decision", decision),
+		slog.Int("score", score),
+		slog.Int("limit", acn.limit),
+	)
+
+	return violations
+}
+
+// Evaluate - Returns an integer array with violation codes found for the,
+// transaction checked with the given rules, without applying it.
+func (acn *Account) Evaluate(tsn *Transaction, rules *Rules) []int {
+	if acn.Initialized() {
+		acn.mu.Lock()
+		defer acn.mu.Unlock()
+	}
+
+	return acn.evaluate(context.Background(), tsn, rules)
+}
+
+// evaluate - Evaluate implementation, the lock must be held.
+func (acn *Account) evaluate(ctx context.Context, tsn *Transaction, rules *Rules) []int {
 	violations := []int{}
 	// Only if the account is initialized, is worthy to look if more,
 	// violations are detected for the transaction.
-	if acn.Initialized() {
-		// If account is active we still continue with validations.
-		// Does not make sense try to apply a transaction with an account,
-		// that is not active.
-		if acn.Active() {
+	if !acn.check(ctx, 0, func() bool { return !acn.Initialized() }) {
+		// If account card is active we still continue with validations.
+		// Does not make sense try to apply a transaction with a card,
+		// that is not active, each state has its own violation.
+		code, unusable := acn.cardViolation()
+		if !acn.check(ctx, code, func() bool { return unusable }) {
+			// An active card may be expired at the transaction time.
+			if acn.expiry != "" && acn.check(ctx, 16, func() bool { return acn.cardExpired(tsn) }) {
+				violations = append(violations, 16)
+				return violations
+			}
+
+			// A card locked by too many declines is declined until unlocked.
+			if acn.check(ctx, 12, func() bool { return acn.locked }) {
+				violations = append(violations, 12)
+				return violations
+			}
+
+			// A negative amount would increase the limit, there is nothing,
+			// else to check.
+			if acn.check(ctx, 11, func() bool { return tsn.Amount < 0 }) {
+				violations = append(violations, 11)
+				return violations
+			}
+
 			// Check if transaction is not duplicated.
-			duplicated := acn.duplicatedTransaction(tsn)
+			duplicated := acn.check(ctx, 4, func() bool { return acn.duplicatedTransaction(tsn, rules) })
 			if duplicated {
 				violations = append(violations, 4)
 			}
 
+			// Or if is not near to an authored one, when the check is enabled.
+			suspected := !duplicated && acn.check(ctx, 10, func() bool { return acn.suspectedDoubledTransaction(tsn, rules) })
+			if suspected {
+				violations = append(violations, 10)
+			}
+
 			// Or if we don't reach the frequency limit.
-			overpassed := acn.frenquencyOverpass(tsn)
+			overpassed := acn.check(ctx, 5, func() bool { return acn.frenquencyOverpass(tsn, rules) })
 			if overpassed {
 				violations = append(violations, 5)
 			}
 
-			// Finally if  not duplicated nor overpassed, we check if the account,
-			// has enoght limit to execute the transation.
-			if !duplicated && !overpassed {
-				if acn.limit < tsn.Amount {
+			// Or if we don't reach the amount allowed to spend in the interval.
+			amountOverpassed := acn.check(ctx, 9, func() bool { return acn.amountOverpass(tsn, rules) })
+			if amountOverpassed {
+				violations = append(violations, 9)
+			}
+
+			// Finally if  not declined by duplicated nor overpassed, we check,
+			// if the account has enoght limit to execute the transation.
+			if decision, _ := rules.Decide(violations); decision != Declined {
+				if acn.check(ctx, 3, func() bool { return acn.limit < tsn.Amount }) {
 					violations = append(violations, 3)
 				}
 			}
 
 			// Check if the merchant is not blocked
-			merchantBlocked := acn.merchantBlocked(tsn)
+			merchantBlocked := acn.check(ctx, 6, func() bool { return acn.merchantBlocked(tsn) })
 			if merchantBlocked {
 				violations = append(violations, 6)
 			}
 
+			// Then check the account's own merchant lists, a denied merchant,
+			// takes precedence over the allowlist.
+			if acn.check(ctx, 7, func() bool { return acn.merchantDenied(tsn) }) {
+				violations = append(violations, 7)
+			} else if acn.check(ctx, 8, func() bool { return !acn.merchantAllowed(tsn) }) {
+				violations = append(violations, 8)
+			}
+
 		} else {
 			// Card not active.
-			violations = append(violations, 1)
+			violations = append(violations, code)
 		}
 	} else {
 		// Account not initialized.
 		violations = append(violations, 0)
 	}
 
-	// If no violations found apply the transaction and register in history.
-	if len(violations) == 0 {
-		acn.limit = acn.limit - tsn.Amount
-		acn.registryTransaction(tsn)
+	return violations
+}
+
+// check - Returns the result of the $found check of the violation $code,
+// logged as debug, and run in a "check" span named by the violation when,
+// the context span is recording, with the violation code, and if it was,
+// found as attributes. The lock must be held.
+func (acn *Account) check(ctx context.Context, code int, found func() bool) bool {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return acn.log(ctx, code, found())
 	}
 
-	return violations
+	_, span := parent.TracerProvider().Tracer("authorizer/account").Start(ctx, "check "+Violations[code])
+	defer span.End()
+	result := found()
+	span.SetAttributes(
+		attribute.Int("violation.code", code),
+		attribute.Bool("violation.found", result),
+	)
+
+	return acn.log(ctx, code, result)
+}
+
+// log - Logs the result of the check of the violation $code as debug,
+// and returns it.
+func (acn *Account) log(ctx context.Context, code int, found bool) bool {
+	acn.currentLogger().LogAttrs(ctx, slog.LevelDebug, "check",
+		slog.String("violation", Violations[code]),
+		slog.Int("code", code),
+		slog.Bool("found", found),
+	)
+
+	return found
 }
 
 // merchantBlocked - return a boolean if the transaction merchant is within
@@ -159,15 +410,103 @@ func (acn *Account) merchantBlocked(tsn *Transaction) bool {
 	return false
 }
 
+// ApplyUpdate - changes the account's merchant lists, card lock, state,
+// and expiry, and returns an integer array with violation codes found.
+// Card states not allowed from the current one, or expiries not valid,
+// are reported and nothing is changed.
+func (acn *Account) ApplyUpdate(upd *Update) []int {
+	violations := []int{}
+	// Nothing to update on an account that does not exist.
+	if !acn.Initialized() {
+		violations = append(violations, 0)
+		return violations
+	}
+
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	violations = append(violations, ValidateCard("", upd.CardExpiry)...)
+	if upd.CardState != "" && !acn.cardTransition(upd.CardState) {
+		violations = append(violations, 18)
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+
+	if upd.AllowedMerchants != nil {
+		acn.allowlist = upd.AllowedMerchants
+	}
+
+	if upd.DeniedMerchants != nil {
+		acn.denylist = upd.DeniedMerchants
+	}
+
+	if upd.Locked != nil {
+		acn.setLocked(*upd.Locked)
+	}
+
+	if upd.CardState != "" {
+		acn.card = upd.CardState
+	}
+
+	if upd.CardExpiry != "" {
+		acn.expiry = upd.CardExpiry
+	}
+
+	return violations
+}
+
+// AllowedMerchants - Returns the merchants the account exclusively allows.
+func (acn *Account) AllowedMerchants() []string {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.allowlist
+}
+
+// DeniedMerchants - Returns the merchants blocked by the account.
+func (acn *Account) DeniedMerchants() []string {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.denylist
+}
+
+// merchantDenied - return a boolean if the transaction merchant is within
+// the account's denylist.
+func (acn *Account) merchantDenied(tsn *Transaction) bool {
+	for _, val := range acn.denylist {
+		if tsn.Merchant == val {
+			return true
+		}
+	}
+
+	return false
+}
+
+// merchantAllowed - return a boolean if the transaction merchant is within
+// the account's allowlist, an empty allowlist allows every merchant.
+func (acn *Account) merchantAllowed(tsn *Transaction) bool {
+	if len(acn.allowlist) == 0 {
+		return true
+	}
+
+	for _, val := range acn.allowlist {
+		if tsn.Merchant == val {
+			return true
+		}
+	}
+
+	return false
+}
+
 // registryTransaction - Adds a new transation to the account authored,
-// transactions history.
-func (acn *Account) registryTransaction(tsn *Transaction) {
+// transactions history, with its outcome.
+func (acn *Account) registryTransaction(tsn *Transaction, o outcome) {
 	acn.transactions = append(acn.transactions, tsn)
+	acn.outcomes = append(acn.outcomes, o)
 }
 
 // duplicatedTransaction - check if a transaction with same amount and merchant,
-// does not exist in a timeframe of 2 minutes diff.
-func (acn *Account) duplicatedTransaction(tsn *Transaction) bool {
+// does not exist in a timeframe of $Interval minutes diff.
+func (acn *Account) duplicatedTransaction(tsn *Transaction, rules *Rules) bool {
 	for _, val := range acn.transactions {
 		// Convert strings to time golang objects.
 		t1, _ := time.Parse(time.RFC3339, val.Time)
@@ -178,7 +517,29 @@ func (acn *Account) duplicatedTransaction(tsn *Transaction) bool {
 		// in authored account's transactions.
 		if val.Amount == tsn.Amount &&
 			val.Merchant == tsn.Merchant &&
-			diff <= maxTimeDiff {
+			diff <= float64(rules.Interval) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suspectedDoubledTransaction - check if a transaction with near amount and,
+// similar merchant does not exist in a timeframe of $Interval minutes diff.
+func (acn *Account) suspectedDoubledTransaction(tsn *Transaction, rules *Rules) bool {
+	// Check disabled.
+	if !rules.SuspectDoubled {
+		return false
+	}
+
+	for _, val := range acn.transactions {
+		t1, _ := time.Parse(time.RFC3339, val.Time)
+		t2, _ := time.Parse(time.RFC3339, tsn.Time)
+		diff := math.Abs(t1.Sub(t2).Minutes())
+		if diff <= float64(rules.Interval) &&
+			rules.amountsNear(val.Amount, tsn.Amount) &&
+			rules.merchantsNear(val.Merchant, tsn.Merchant) {
 			return true
 		}
 	}
@@ -187,23 +548,45 @@ func (acn *Account) duplicatedTransaction(tsn *Transaction) bool {
 }
 
 // frenquencyOverpass - Returns true if the current transaction breaks the,
-// number of transactions allowed ($maxTransactions) in the frequency,
-// of $maxTimeDiff minutes.
-func (acn *Account) frenquencyOverpass(tsn *Transaction) bool {
+// number of transactions allowed ($MaxTransactions) in the frequency,
+// of $Interval minutes.
+func (acn *Account) frenquencyOverpass(tsn *Transaction, rules *Rules) bool {
 	allowed := 0
 	for _, val := range acn.transactions {
 		t1, _ := time.Parse(time.RFC3339, val.Time)
 		t2, _ := time.Parse(time.RFC3339, tsn.Time)
 		diff := math.Abs(t1.Sub(t2).Minutes())
-		if diff <= maxTimeDiff {
+		if diff <= float64(rules.Interval) {
 			allowed++
 		}
 		// If we reach the maxnumber of transactions allowed, we can't go for it,
 		// and the violation is reported.
-		if allowed == maxTransactions {
+		if allowed == rules.MaxTransactions {
 			return true
 		}
 	}
 
 	return false
 }
+
+// amountOverpass - Returns true if the current transaction breaks the,
+// amount allowed to spend ($MaxAmount) in the frequency of $AmountInterval,
+// minutes.
+func (acn *Account) amountOverpass(tsn *Transaction, rules *Rules) bool {
+	// Check disabled.
+	if rules.MaxAmount <= 0 {
+		return false
+	}
+
+	spent := tsn.Amount
+	for _, val := range acn.transactions {
+		t1, _ := time.Parse(time.RFC3339, val.Time)
+		t2, _ := time.Parse(time.RFC3339, tsn.Time)
+		diff := math.Abs(t1.Sub(t2).Minutes())
+		if diff <= float64(rules.AmountInterval) {
+			spent += val.Amount
+		}
+	}
+
+	return spent > rules.MaxAmount
+}
@@ -3,18 +3,42 @@
 // *                                                                  *
 // * 2020-03-15 First Version, JR                                     *
 // * 2020-03-18 Adds multiple violation scnario, JR                   *
+// * 2026-10-19 Adds account merchant lists scenarios, JR             *
+// * 2026-10-19 Adds amount velocity scenarios, JR                    *
+// * 2026-10-19 Adds suspected doubled scenarios, JR                  *
+// * 2026-10-19 Adds flagged violations scenarios, JR                 *
+// * 2026-10-19 Adds invalid amount scenario, JR                      *
+// * 2026-10-19 Adds ApplyTransaction benchmarks, JR                  *
+// * 2026-10-19 Adds concurrent transactions stress test, JR          *
+// * 2026-10-19 Adds rules version test, JR                           *
+// * 2026-10-19 Adds transaction checks spans test, JR                *
+// * 2026-10-19 Adds transaction checks logs test, JR                 *
+// * 2026-10-19 Adds invalid amount action scenario, JR               *
+// * 2026-10-19 Adds negative limit scenario, JR                      *
 // *                                                                  *
 // * This file contains all unit-test representations related         *
 // * with the Account struct.                                         *
 // *                                                                  *                                                               *
 // * Usage: go test -v ./account                                      *
+// * Race: go test -race -run Concurrent ./account                    *
+// * Benchmarks: go test -run XXX -bench . ./account                  *
 // ********************************************************************
 
 package account
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test Account limit to all our testcases.
@@ -26,26 +50,26 @@ var taccounts = map[string]*Account{
 	"NotInitialzed": nil,
 
 	"NotActive": {
-		active: false,
-		limit:  tlimit,
+		card:  CardInactive,
+		limit: tlimit,
 	},
 
 	"Active": {
-		active: true,
-		limit:  tlimit,
+		card:  CardActive,
+		limit: tlimit,
 	},
 
 	"WithTransaction": {
-		active: true,
-		limit:  tlimit,
+		card:  CardActive,
+		limit: tlimit,
 		transactions: []*Transaction{
 			ttransactions["Valid"],
 		},
 	},
 
 	"WithHighFrequencyLimit": {
-		active: true,
-		limit:  tlimit,
+		card:  CardActive,
+		limit: tlimit,
 		transactions: []*Transaction{
 			{
 				Merchant: "Fulanito1",
@@ -68,8 +92,8 @@ var taccounts = map[string]*Account{
 	},
 
 	"WithHighFrequencyDoubled": {
-		active: true,
-		limit:  tlimit,
+		card:  CardActive,
+		limit: tlimit,
 		transactions: []*Transaction{
 			{
 				Merchant: "Fulanito1",
@@ -86,6 +110,75 @@ var taccounts = map[string]*Account{
 			ttransactions["Valid"],
 		},
 	},
+
+	"WithDenylist": {
+		card:     CardActive,
+		limit:    tlimit,
+		denylist: []string{"Fulanito"},
+	},
+
+	"WithAllowlist": {
+		card:      CardActive,
+		limit:     tlimit,
+		allowlist: []string{"Menganito"},
+	},
+
+	"WithHighAmount": {
+		card:  CardActive,
+		limit: tlimit * 2,
+		rules: &Rules{
+			Interval:        maxTimeDiff,
+			MaxTransactions: maxTransactions,
+			AmountInterval:  5,
+			MaxAmount:       tlimit + 50,
+		},
+		transactions: []*Transaction{
+			{
+				Merchant: "Fulanito1",
+				Amount:   60,
+				Time:     "2019-02-13T09:56:00.000Z",
+			},
+		},
+	},
+
+	"WithSuspectedDoubled": {
+		card:  CardActive,
+		limit: tlimit * 2,
+		rules: &Rules{
+			Interval:           maxTimeDiff,
+			MaxTransactions:    maxTransactions,
+			SuspectDoubled:     true,
+			AmountTolerance:    5,
+			TolerancePercent:   true,
+			MerchantSimilarity: 0.8,
+		},
+		transactions: []*Transaction{
+			{
+				Merchant: "fulanito ",
+				Amount:   96,
+				Time:     "2019-02-13T10:01:00.000Z",
+			},
+		},
+	},
+
+	"WithFlaggedDoubled": {
+		card:  CardActive,
+		limit: tlimit * 2,
+		rules: &Rules{
+			Interval:        maxTimeDiff,
+			MaxTransactions: maxTransactions,
+			Actions:         map[int]string{4: Flag},
+		},
+		transactions: []*Transaction{
+			ttransactions["Valid"],
+		},
+	},
+
+	"ToUpdate": {
+		card:      CardActive,
+		limit:     tlimit,
+		allowlist: []string{"Menganito"},
+	},
 }
 
 // Test Transctions to cover our test scenarios.
@@ -96,6 +189,12 @@ var ttransactions = map[string]*Transaction{
 		Time:     "2019-02-13T10:00:00.000Z",
 	},
 
+	"Negative": {
+		Merchant: "Fulanito",
+		Amount:   -tlimit,
+		Time:     "2019-02-13T10:00:00.000Z",
+	},
+
 	"Insufficient": {
 		Merchant: "",
 		Amount:   tlimit + 1,
@@ -259,3 +358,365 @@ func TestAccountInitializationAccountInitialized(t *testing.T) {
 	assert := assert.New(t)
 	assert.Equal(2, v, "Violations code for initializaton expected.")
 }
+
+// Test transaction to a merchant denied by the account.
+func TestAccountDeniedMerchantTransaction(t *testing.T) {
+	violations := taccounts["WithDenylist"].ApplyTransaction(ttransactions["Valid"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{7},
+		violations,
+		"Expected array with violation code 7.",
+	)
+}
+
+// Test transaction to a merchant out of the account's allowlist.
+func TestAccountNotAllowedMerchantTransaction(t *testing.T) {
+	violations := taccounts["WithAllowlist"].ApplyTransaction(ttransactions["Valid"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{8},
+		violations,
+		"Expected array with violation code 8.",
+	)
+}
+
+// Test update over not initialized account.
+func TestAccountNotInitializedUpdate(t *testing.T) {
+	violations := taccounts["NotInitialzed"].ApplyUpdate(&Update{})
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{0},
+		violations,
+		"Expected array with violation code 0.",
+	)
+}
+
+// Test update of the account merchant lists.
+func TestAccountUpdate(t *testing.T) {
+	acn := taccounts["ToUpdate"]
+	violations := acn.ApplyUpdate(&Update{
+		DeniedMerchants: []string{"Fulanito"},
+	})
+	assert := assert.New(t)
+	assert.Equal([]int{}, violations, "Expected no violations.")
+	assert.Equal(
+		[]string{"Menganito"},
+		acn.AllowedMerchants(),
+		"Expected allowlist was kept.",
+	)
+	assert.Equal(
+		[]string{"Fulanito"},
+		acn.DeniedMerchants(),
+		"Expected denylist was set.",
+	)
+
+	// An empty list clears the allowlist.
+	acn.ApplyUpdate(&Update{AllowedMerchants: []string{}})
+	assert.Empty(acn.AllowedMerchants(), "Expected allowlist was cleared.")
+}
+
+// Test a high amount in a small interval transaction.
+func TestAccountHighAmountTransaction(t *testing.T) {
+	violations := taccounts["WithHighAmount"].ApplyTransaction(ttransactions["Valid"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{9},
+		violations,
+		"Expected array with violation code 9.",
+	)
+}
+
+// Test default rules when none set to the account.
+func TestAccountDefaultRules(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(
+		DefaultRules(),
+		taccounts["Active"].Rules(),
+		"Expected default rules.",
+	)
+	assert.Equal(0, DefaultRules().MaxAmount, "Expected amount check disabled.")
+}
+
+// Test a suspected doubled transaction.
+func TestAccountSuspectedDoubledTransaction(t *testing.T) {
+	violations := taccounts["WithSuspectedDoubled"].ApplyTransaction(ttransactions["Valid"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{10},
+		violations,
+		"Expected array with violation code 10.",
+	)
+}
+
+// Test near amounts and similar merchants rules.
+func TestRulesNearTransactions(t *testing.T) {
+	rules := DefaultRules()
+	assert := assert.New(t)
+	assert.True(rules.amountsNear(50, 48), "Expected amounts within 5%.")
+	assert.False(rules.amountsNear(50, 47), "Expected amounts out of 5%.")
+
+	rules.TolerancePercent = false
+	rules.AmountTolerance = 1
+	assert.True(rules.amountsNear(50, 49), "Expected amounts within 1.")
+	assert.False(rules.amountsNear(50, 48), "Expected amounts out of 1.")
+
+	assert.True(rules.merchantsNear("Burger Queen", "burger queen"), "Expected same merchants.")
+	assert.True(rules.merchantsNear("Burger Queen", "Burger Quen"), "Expected similar merchants.")
+	assert.False(rules.merchantsNear("Burger Queen", "Habbib's"), "Expected different merchants.")
+}
+
+// Test a doubled transaction flagged instead of declined.
+func TestAccountFlaggedTransaction(t *testing.T) {
+	acn := taccounts["WithFlaggedDoubled"]
+	violations := acn.ApplyTransaction(ttransactions["Valid"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{4},
+		violations,
+		"Expected array with violation code 4.",
+	)
+	assert.Equal(tlimit, acn.Limit(), "Expected account limit was reduced.")
+	assert.Len(acn.transactions, 2, "Expected transaction was registered.")
+}
+
+// Test decisions and risk scores from violations found.
+func TestRulesDecide(t *testing.T) {
+	rules := DefaultRules()
+	rules.Actions = map[int]string{1: Flag, 5: Flag, 9: Review}
+	rules.Scores = map[int]int{9: 15}
+	assert := assert.New(t)
+
+	decision, score := rules.Decide([]int{})
+	assert.Equal(Approved, decision, "Expected an approved transaction.")
+	assert.Equal(0, score, "Expected no risk score.")
+
+	decision, score = rules.Decide([]int{5, 9})
+	assert.Equal(ApprovedWithFlags, decision, "Expected a flagged transaction.")
+	assert.Equal(defaultScores[5]+15, score, "Expected aggregate risk score.")
+
+	decision, _ = rules.Decide([]int{4, 5})
+	assert.Equal(Declined, decision, "Expected a declined transaction.")
+
+	decision, _ = rules.Decide([]int{1})
+	assert.Equal(Declined, decision, "Expected hard violations declined.")
+
+	rules.MaxScore = defaultScores[5] + 15
+	decision, _ = rules.Decide([]int{5, 9})
+	assert.Equal(Declined, decision, "Expected declined by max score.")
+}
+
+// Test violation codes by name.
+func TestViolationCode(t *testing.T) {
+	assert := assert.New(t)
+	code, ok := Code("doubled-transaction")
+	assert.True(ok, "Expected a known violation.")
+	assert.Equal(4, code, "Expected violation code 4.")
+	_, ok = Code("unknown")
+	assert.False(ok, "Expected an unknown violation.")
+}
+
+// Test rules versions change only with the configuration.
+func TestRulesVersion(t *testing.T) {
+	var none *Rules
+	rules := DefaultRules()
+	assert := assert.New(t)
+	assert.Len(rules.Version(), 12, "Expected a 12 chars version.")
+	assert.Equal(rules.Version(), DefaultRules().Version(), "Expected same version.")
+	assert.Equal(rules.Version(), none.Version(), "Expected default version.")
+
+	rules.Actions[5] = Flag
+	assert.NotEqual(DefaultRules().Version(), rules.Version(), "Expected new version.")
+}
+
+// Test transaction with a negative amount.
+func TestAccountInvalidAmountTransaction(t *testing.T) {
+	acn, _ := taccounts["NotInitialzed"].Init(true, tlimit)
+	violations := acn.ApplyTransaction(ttransactions["Negative"])
+	assert := assert.New(t)
+	assert.Equal(
+		[]int{11},
+		violations,
+		"Expected array with violation code 11.",
+	)
+	assert.Equal(tlimit, acn.Limit(), "Expected same account limit.")
+}
+
+// Test an account is not created with a negative limit.
+func TestAccountNegativeLimit(t *testing.T) {
+	acn, v := taccounts["NotInitialzed"].Init(true, -1)
+	assert := assert.New(t)
+	assert.Equal(11, v, "Expected violation code 11.")
+	assert.False(acn.Initialized(), "Expected account not initialized.")
+	_, v = taccounts["NotInitialzed"].Init(true, 0)
+	assert.Equal(-1, v, "Expected a zero limit account.")
+}
+
+// Test a negative amount is declined whatever its action, with no other,
+// check run, while a zero amount is applied.
+func TestAccountInvalidAmountHard(t *testing.T) {
+	acn, _ := taccounts["NotInitialzed"].Init(true, tlimit)
+	rules := DefaultRules()
+	rules.Actions = map[int]string{11: Flag}
+	acn.SetRules(rules)
+	assert := assert.New(t)
+
+	assert.Equal([]int{11}, acn.ApplyTransaction(&Transaction{Merchant: "Fulanito", Amount: -1, Time: "2019-02-13T10:00:00.000Z"}))
+	decision, _ := rules.Decide([]int{11})
+	assert.Equal(Declined, decision)
+	assert.Equal(tlimit, acn.Limit(), "Expected same account limit.")
+	assert.Empty(acn.ApplyTransaction(&Transaction{Merchant: "Fulanito", Amount: 0, Time: "2019-02-13T10:01:00.000Z"}))
+}
+
+// Test concurrent transactions never overspend the account limit,
+// twice the transactions the limit allows are applied at the same time,
+// one hour apart and by different merchants, so only the limit declines.
+func TestAccountConcurrentTransactions(t *testing.T) {
+	const amount = 10
+	const transactions = 2 * tlimit / amount
+	acn, _ := taccounts["NotInitialzed"].Init(true, tlimit)
+	start := time.Date(2019, 2, 13, 10, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	approved := make(chan int, transactions)
+	for i := 0; i < transactions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			violations := acn.ApplyTransaction(&Transaction{
+				Merchant: fmt.Sprintf("Fulanito%d", i),
+				Amount:   amount,
+				Time:     start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			})
+			if len(violations) == 0 {
+				approved <- amount
+			}
+			// Readers run next to the writers.
+			acn.Limit()
+			acn.Rules()
+		}(i)
+	}
+	wg.Wait()
+	close(approved)
+
+	spent := 0
+	for a := range approved {
+		spent += a
+	}
+	assert := assert.New(t)
+	assert.Equal(tlimit, spent, "Expected the whole limit spent.")
+	assert.Equal(0, acn.Limit(), "Expected no limit left.")
+}
+
+// Benchmark a transaction applied to accounts with growing histories,
+// one transaction a minute, so only the last ones are in the window.
+func BenchmarkApplyTransaction(b *testing.B) {
+	start := time.Date(2019, 2, 13, 10, 0, 0, 0, time.UTC)
+	for _, size := range []int{10, 100, 1000, 10000} {
+		history := make([]*Transaction, size)
+		for i := range history {
+			history[i] = &Transaction{
+				Merchant: fmt.Sprintf("Fulanito%d", i%10),
+				Amount:   10,
+				Time:     start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339),
+			}
+		}
+		tsn := &Transaction{
+			Merchant: "Fulanito",
+			Amount:   10,
+			Time:     start.Add(time.Duration(size+10) * time.Minute).Format(time.RFC3339),
+		}
+
+		b.Run(fmt.Sprintf("history-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// Same history each time, the transaction is not kept.
+				acn := &Account{
+					card:         CardActive,
+					limit:        tlimit,
+					transactions: history[:size:size],
+				}
+				acn.ApplyTransaction(tsn)
+			}
+		})
+	}
+}
+
+// Test every transaction check runs in a span, child of the context one,
+// only when it is recording.
+func TestAccountTransactionSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	acn, _ := (*Account)(nil).Init(true, tlimit)
+	acn.ApplyTransaction(&Transaction{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"})
+	assert := assert.New(t)
+	assert.Empty(recorder.Ended(), "Expected no spans without a recording one.")
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	violations := acn.ApplyTransactionContext(ctx, &Transaction{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:01:00.000Z"})
+	parent.End()
+	assert.Equal([]int{4}, violations)
+
+	found := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.Name() == "parent" {
+			continue
+		}
+		assert.Equal(parent.SpanContext().SpanID(), span.Parent().SpanID(), "Expected a child of the context span.")
+		for _, attr := range span.Attributes() {
+			if attr.Key == "violation.found" {
+				found[span.Name()] = attr.Value.AsBool()
+			}
+		}
+	}
+	assert.Equal(map[string]bool{
+		"check account-not-initialized":       false,
+		"check card-not-active":               false,
+		"check too-many-declines":             false,
+		"check invalid-amount":                false,
+		"check doubled-transaction":           true,
+		"check high-frequency-small-interval": false,
+		"check high-amount-small-interval":    false,
+		"check blocked-merchant":              false,
+		"check account-blocked-merchant":      false,
+		"check merchant-not-allowed":          false,
+	}, found, "Expected a span by check run.")
+}
+
+// Test every transaction check and the decision are logged as debug.
+func TestAccountTransactionLogs(t *testing.T) {
+	logs := &bytes.Buffer{}
+	acn, _ := (*Account)(nil).Init(true, tlimit)
+	acn.SetLogger(slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	acn.ApplyTransaction(&Transaction{Merchant: "Burger King", Amount: 20, Time: "2019-02-13T10:00:00.000Z"})
+
+	checks := map[string]bool{}
+	var decision map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "DEBUG", record["level"])
+		if record["msg"] == "check" {
+			checks[record["violation"].(string)] = record["found"].(bool)
+		} else {
+			decision = record
+		}
+	}
+
+	assert := assert.New(t)
+	assert.Len(checks, 12, "Expected a record by check run.")
+	assert.True(checks["blocked-merchant"])
+	assert.False(checks["insufficient-limit"])
+	assert.Equal("transaction decided", decision["msg"])
+	assert.Equal(Declined, decision["decision"])
+	assert.Equal([]interface{}{6.0}, decision["violations"])
+	assert.Equal(float64(tlimit), decision["limit"])
+
+	// Without logger nothing is logged.
+	logs.Reset()
+	acn.SetLogger(nil)
+	acn.ApplyTransaction(&Transaction{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"})
+	assert.Empty(logs.String())
+}
@@ -0,0 +1,154 @@
+// ********************************************************************
+// * card.go                                                          *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Card lifecycle of an account, its state changed by account       *
+// * updates through the allowed transitions only, and its expiry     *
+// * month, checked against the transactions time. Every state but    *
+// * active declines the transactions with its own violation.         *
+// *                                                                  *
+// * Usage:                                                           *
+// * acn.InitCard(account.CardActive, "2027-12")                      *
+// * acn.ApplyUpdate(&account.Update{CardState: account.CardLost})    *
+// ********************************************************************
+
+package account
+
+import (
+	"time"
+)
+
+// Card states.
+const (
+	CardActive   = "active"
+	CardInactive = "inactive"
+	CardBlocked  = "blocked"
+	CardLost     = "lost"
+	CardStolen   = "stolen"
+	CardExpired  = "expired"
+	CardClosed   = "closed"
+)
+
+// Layout of the card expiry, the card is valid through the whole month.
+const expiryLayout = "2006-01"
+
+// CardStates - Card states, in their lifecycle order.
+var CardStates = []string{CardActive, CardInactive, CardBlocked, CardLost, CardStolen, CardExpired, CardClosed}
+
+// cardTransitions - States a card can change to, by state. Lost and stolen,
+// cards are never used again, and closed is final.
+var cardTransitions = map[string][]string{
+	CardActive:   {CardInactive, CardBlocked, CardLost, CardStolen, CardExpired, CardClosed},
+	CardInactive: {CardActive, CardBlocked, CardLost, CardStolen, CardExpired, CardClosed},
+	CardBlocked:  {CardActive, CardInactive, CardLost, CardStolen, CardClosed},
+	CardLost:     {CardStolen, CardClosed},
+	CardStolen:   {CardClosed},
+	CardExpired:  {CardActive, CardClosed},
+	CardClosed:   {},
+}
+
+// cardViolations - Violation code declining transactions, by card state.
+var cardViolations = map[string]int{
+	CardInactive: 1,
+	CardBlocked:  13,
+	CardLost:     14,
+	CardStolen:   15,
+	CardExpired:  16,
+	CardClosed:   17,
+}
+
+// ValidateCard - Returns an integer array with violation codes found for,
+// a card state and expiry an account is created with, empty ones are valid.
+func ValidateCard(state string, expiry string) []int {
+	violations := []int{}
+	if _, ok := cardTransitions[state]; state != "" && !ok {
+		violations = append(violations, 18)
+	}
+	if _, err := time.Parse(expiryLayout, expiry); expiry != "" && err != nil {
+		violations = append(violations, 19)
+	}
+
+	return violations
+}
+
+// InitCard - Sets the card state and expiry of an account just created,
+// empty ones are kept, they must be valid for ValidateCard.
+func (acn *Account) InitCard(state string, expiry string) {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	if state != "" {
+		acn.card = state
+	}
+	if expiry != "" {
+		acn.expiry = expiry
+	}
+}
+
+// Card - Returns the card state.
+func (acn *Account) Card() string {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.card
+}
+
+// Expiry - Returns the card expiry month, empty if the card never expires.
+func (acn *Account) Expiry() string {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.expiry
+}
+
+// cardState - Returns the initial card state of the active flag.
+func cardState(active bool) string {
+	if active {
+		return CardActive
+	}
+
+	return CardInactive
+}
+
+// cardTransition - Returns true if the card can change to the state, the,
+// current state is always allowed.
+func (acn *Account) cardTransition(state string) bool {
+	if state == acn.card {
+		return true
+	}
+	for _, val := range cardTransitions[acn.card] {
+		if val == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cardViolation - Returns the violation code of the card state and true,
+// if it is not usable, or the card-not-active code and false if it is.
+// Only active cards are usable.
+func (acn *Account) cardViolation() (int, bool) {
+	if acn.card == CardActive {
+		return 1, false
+	}
+	if code, ok := cardViolations[acn.card]; ok {
+		return code, true
+	}
+
+	return 1, true
+}
+
+// cardExpired - Returns true if the transaction time is after the card,
+// expiry month, transactions with a time not valid are not checked.
+func (acn *Account) cardExpired(tsn *Transaction) bool {
+	if acn.expiry == "" {
+		return false
+	}
+
+	expiry, _ := time.Parse(expiryLayout, acn.expiry)
+	t, err := time.Parse(time.RFC3339, tsn.Time)
+	if err != nil {
+		return false
+	}
+
+	return !t.Before(expiry.AddDate(0, 1, 0))
+}
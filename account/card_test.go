@@ -0,0 +1,106 @@
+// ********************************************************************
+// * card_test.go                                                     *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the card        *
+// * lifecycle states, their transitions and the card expiry.         *
+// *                                                                  *
+// * Usage: go test -v -run Card ./account                            *
+// ********************************************************************
+
+package account
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// tcardTransaction - Returns a valid transaction at the time.
+func tcardTransaction(time string) *Transaction {
+	return &Transaction{Merchant: "Habbib's", Amount: 10, Time: time}
+}
+
+// Test every state but active declines with its own violation.
+func TestCardStatesViolations(t *testing.T) {
+	for state, code := range map[string]int{
+		CardInactive: 1,
+		CardBlocked:  13,
+		CardLost:     14,
+		CardStolen:   15,
+		CardExpired:  16,
+		CardClosed:   17,
+	} {
+		t.Run(state, func(t *testing.T) {
+			acn, _ := (*Account)(nil).Init(true, 100)
+			acn.InitCard(state, "")
+			assert.Equal(t, []int{code}, acn.ApplyTransaction(tcardTransaction("2019-02-13T10:00:00.000Z")))
+			assert.Equal(t, 100, acn.Limit())
+			assert.False(t, acn.Active())
+		})
+	}
+}
+
+// Test the card state changes only through the allowed transitions.
+func TestCardTransitions(t *testing.T) {
+	acn, _ := (*Account)(nil).Init(false, 100)
+	assert := assert.New(t)
+	assert.Equal(CardInactive, acn.Card())
+
+	for _, tt := range []struct {
+		state      string
+		violations []int
+		card       string
+	}{
+		{CardActive, []int{}, CardActive},
+		{CardActive, []int{}, CardActive},
+		{CardBlocked, []int{}, CardBlocked},
+		{"frozen", []int{18}, CardBlocked},
+		{CardLost, []int{}, CardLost},
+		{CardActive, []int{18}, CardLost},
+		{CardStolen, []int{}, CardStolen},
+		{CardLost, []int{18}, CardStolen},
+		{CardClosed, []int{}, CardClosed},
+		{CardActive, []int{18}, CardClosed},
+	} {
+		assert.Equal(tt.violations, acn.ApplyUpdate(&Update{CardState: tt.state}), "Updating to %s.", tt.state)
+		assert.Equal(tt.card, acn.Card())
+	}
+}
+
+// Test an update not valid changes nothing.
+func TestCardUpdateNotValid(t *testing.T) {
+	acn, _ := (*Account)(nil).Init(true, 100)
+	assert := assert.New(t)
+
+	assert.Equal([]int{19}, acn.ApplyUpdate(&Update{CardState: CardBlocked, CardExpiry: "12/27"}))
+	acn.InitCard(CardClosed, "")
+	assert.Equal([]int{18}, acn.ApplyUpdate(&Update{CardState: CardActive, DeniedMerchants: []string{"Subway"}}))
+	assert.Equal(CardClosed, acn.Card())
+	assert.Empty(acn.DeniedMerchants())
+	assert.Equal([]int{18, 19}, ValidateCard("frozen", "2027-13"))
+	assert.Empty(ValidateCard("", ""))
+}
+
+// Test the card expires after its expiry month, and is renewed by an update.
+func TestCardExpiry(t *testing.T) {
+	acn, _ := (*Account)(nil).Init(true, 100)
+	acn.InitCard("", "2019-02")
+	assert := assert.New(t)
+
+	assert.Equal(CardActive, acn.Card())
+	assert.Equal("2019-02", acn.Expiry())
+	assert.Equal([]int{}, acn.ApplyTransaction(tcardTransaction("2019-02-28T23:59:59.000Z")))
+	assert.Equal([]int{16}, acn.ApplyTransaction(tcardTransaction("2019-03-01T00:00:00.000Z")))
+	// Transactions with a time not valid are not checked.
+	assert.NotContains(acn.ApplyTransaction(tcardTransaction("yesterday")), 16)
+
+	assert.Empty(acn.ApplyUpdate(&Update{CardExpiry: "2022-02"}))
+	assert.Equal([]int{}, acn.ApplyTransaction(tcardTransaction("2019-03-10T00:00:00.000Z")))
+
+	// An expired card is renewed to active.
+	acn.ApplyUpdate(&Update{CardState: CardExpired})
+	assert.Equal([]int{16}, acn.ApplyTransaction(tcardTransaction("2019-03-20T00:00:00.000Z")))
+	assert.Empty(acn.ApplyUpdate(&Update{CardState: CardActive}))
+	assert.True(acn.Active())
+}
@@ -0,0 +1,77 @@
+// ********************************************************************
+// * declines.go                                                      *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Keeps the declined attempts of an account, apart from the        *
+// * authorized transactions, with their violations, and locks the    *
+// * card after $MaxDeclines of them in $DeclineInterval minutes,     *
+// * until it is unlocked by an account update.                       *
+// *                                                                  *
+// * Usage:                                                           *
+// * for e := range acn.History(account.Filter{Declined: true}) {     *
+// * acn.ApplyUpdate(&account.Update{Locked: &unlock})                *
+// ********************************************************************
+
+package account
+
+import (
+	"math"
+	"time"
+)
+
+// decline - A declined attempt, after the number of authorized,
+// transactions registered when it was declined, to keep the history order.
+type decline struct {
+	entry Entry
+	after int
+}
+
+// Locked - Returns if the card is locked by too many declines.
+func (acn *Account) Locked() bool {
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	return acn.locked
+}
+
+// registryDecline - Adds a declined attempt to the account declines, with,
+// its violations, and locks the card if there are too many of them.
+func (acn *Account) registryDecline(tsn *Transaction, violations []int, rules *Rules) {
+	acn.declines = append(acn.declines, decline{
+		entry: Entry{Transaction: *tsn, Decision: Declined, Violations: append([]int{}, violations...)},
+		after: len(acn.transactions),
+	})
+	if acn.tooManyDeclines(tsn, rules) {
+		acn.locked = true
+	}
+}
+
+// tooManyDeclines - Returns true if the declined attempts since the card,
+// was unlocked, in $DeclineInterval minutes of the transaction, reach,
+// $MaxDeclines.
+func (acn *Account) tooManyDeclines(tsn *Transaction, rules *Rules) bool {
+	// Check disabled.
+	if rules.MaxDeclines <= 0 {
+		return false
+	}
+
+	declines := 0
+	t2, _ := time.Parse(time.RFC3339, tsn.Time)
+	for _, val := range acn.declines[acn.unlocked:] {
+		t1, _ := time.Parse(time.RFC3339, val.entry.Time)
+		if math.Abs(t1.Sub(t2).Minutes()) <= float64(rules.DeclineInterval) {
+			declines++
+		}
+	}
+
+	return declines >= rules.MaxDeclines
+}
+
+// setLocked - Locks or unlocks the card, an unlocked card only counts,
+// the declines from then. The lock must be held.
+func (acn *Account) setLocked(locked bool) {
+	acn.locked = locked
+	if !locked {
+		acn.unlocked = len(acn.declines)
+	}
+}
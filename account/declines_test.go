@@ -0,0 +1,101 @@
+// ********************************************************************
+// * declines_test.go                                                 *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the declined    *
+// * attempts kept, and the card lock by too many declines.           *
+// *                                                                  *
+// * Usage: go test -v -run Decline ./account                         *
+// ********************************************************************
+
+package account
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// tdeclinesAccount - Returns an account with a limit of 100, locked after,
+// $max declines in 10 minutes.
+func tdeclinesAccount(max int) *Account {
+	acn, _ := (*Account)(nil).Init(true, 100)
+	rules := DefaultRules()
+	rules.MaxDeclines = max
+	rules.DeclineInterval = 10
+	acn.SetRules(rules)
+
+	return acn
+}
+
+// tdecline - Returns a transaction over the limit, $minute minutes after,
+// 10:00.
+func tdecline(minute int) *Transaction {
+	return &Transaction{Merchant: "Subway", Amount: 500, Time: fmt.Sprintf("2019-02-13T10:%02d:00.000Z", minute)}
+}
+
+// Test declined attempts are kept apart, with their violations.
+func TestDeclinesHistory(t *testing.T) {
+	acn := tdeclinesAccount(0)
+	acn.ApplyTransaction(&Transaction{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"})
+	acn.ApplyTransaction(tdecline(1))
+	acn.ApplyTransaction(&Transaction{Merchant: "Habbib's", Amount: 30, Time: "2019-02-13T10:02:00.000Z"})
+	acn.ApplyTransaction(&Transaction{Merchant: "Burger King", Amount: 10, Time: "2019-02-13T10:03:00.000Z"})
+
+	assert := assert.New(t)
+	assert.Equal([]string{"Burger Queen", "Habbib's"}, thistoryMerchants(acn, Filter{}))
+	assert.Equal([]string{"Burger Queen", "Subway", "Habbib's", "Burger King"}, thistoryMerchants(acn, Filter{Declined: true}))
+	entries := []Entry{}
+	for e := range acn.History(Filter{Declined: true, Outcome: Declined}) {
+		entries = append(entries, e)
+	}
+	assert.Equal([]Entry{
+		{Transaction: *tdecline(1), Decision: Declined, Violations: []int{3}},
+		{Transaction: Transaction{Merchant: "Burger King", Amount: 10, Time: "2019-02-13T10:03:00.000Z"}, Decision: Declined, Violations: []int{6}},
+	}, entries)
+	// Declines are not held against the limit, nor checked by the rules.
+	assert.Equal(50, acn.Limit())
+	assert.False(acn.Locked(), "Expected the lock disabled.")
+}
+
+// Test the card is locked after too many declines in the interval, and,
+// the lock is reversed by an update.
+func TestDeclinesLock(t *testing.T) {
+	acn := tdeclinesAccount(3)
+	assert := assert.New(t)
+
+	// Declines out of the interval are not counted.
+	acn.ApplyTransaction(tdecline(0))
+	acn.ApplyTransaction(tdecline(20))
+	acn.ApplyTransaction(tdecline(25))
+	assert.False(acn.Locked())
+	acn.ApplyTransaction(tdecline(30))
+	assert.True(acn.Locked())
+
+	tsn := &Transaction{Merchant: "Habbib's", Amount: 10, Time: "2019-02-13T10:31:00.000Z"}
+	assert.Equal([]int{12}, acn.ApplyTransaction(tsn))
+	summary, _ := acn.Query(&Query{})
+	assert.True(summary.Locked)
+
+	unlock := false
+	assert.Empty(acn.ApplyUpdate(&Update{Locked: &unlock}))
+	assert.False(acn.Locked())
+	// Declines before the unlock are not counted again.
+	acn.ApplyTransaction(tdecline(32))
+	assert.False(acn.Locked())
+	assert.Equal([]int{}, acn.ApplyTransaction(tsn))
+
+	lock := true
+	acn.ApplyUpdate(&Update{Locked: &lock})
+	assert.Equal([]int{12}, acn.ApplyTransaction(&Transaction{Merchant: "Subway", Amount: 1, Time: "2019-02-13T10:40:00.000Z"}))
+	assert.Equal(90, acn.Limit())
+}
+
+// Test an inactive card is not reported as locked.
+func TestDeclinesLockInactive(t *testing.T) {
+	acn := tdeclinesAccount(1)
+	acn.ApplyTransaction(tdecline(0))
+	acn.card = CardInactive
+	assert.Equal(t, []int{1}, acn.ApplyTransaction(tdecline(1)))
+}
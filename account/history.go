@@ -0,0 +1,118 @@
+// ********************************************************************
+// * history.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds declined attempts, JR                            *
+// *                                                                  *
+// * Iterator over the account history, the authorized transactions   *
+// * with their decision and the violations flagged, filtered by      *
+// * time range, merchant and outcome.                                *
+// *                                                                  *
+// * Usage:                                                           *
+// * for e := range acn.History(account.Filter{Merchant: "Subway"}) { *
+// ********************************************************************
+
+package account
+
+import (
+	"iter"
+	"time"
+)
+
+// Entry - represents a transaction of the account history, with its,
+// decision and the violation codes flagged when it was authorized.
+type Entry struct {
+	Transaction
+	Decision   string
+	Violations []int
+}
+
+// Filter - Entries of the history iterated, within $From and $To, both,
+// included, of the $Merchant and with the $Outcome decision. Zero fields,
+// do not filter, transactions with a time not valid are only iterated,
+// without time range. Declined attempts are only iterated if $Declined.
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	Merchant string
+	Outcome  string
+	Declined bool
+}
+
+// outcome - Decision and violations of an authorized transaction.
+type outcome struct {
+	decision   string
+	violations []int
+}
+
+// History - Returns an iterator over a copy of the history entries,
+// matching the filter, in the order they were authorized or declined.
+func (acn *Account) History(f Filter) iter.Seq[Entry] {
+	return func(yield func(Entry) bool) {
+		for _, e := range acn.entries(f.Declined) {
+			if !f.Match(e) {
+				continue
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Match - Returns true if the entry matches the filter.
+func (f Filter) Match(e Entry) bool {
+	if e.Decision == Declined && !f.Declined {
+		return false
+	}
+	if f.Merchant != "" && e.Merchant != f.Merchant {
+		return false
+	}
+	if f.Outcome != "" && e.Decision != f.Outcome {
+		return false
+	}
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+
+	t, err := time.Parse(time.RFC3339, e.Time)
+	if err != nil {
+		return false
+	}
+
+	return !t.Before(f.From) && (f.To.IsZero() || !t.After(f.To))
+}
+
+// entries - Returns a copy of the history entries, with the declined,
+// attempts between them if $declined.
+func (acn *Account) entries(declined bool) []Entry {
+	if !acn.Initialized() {
+		return nil
+	}
+
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	entries := []Entry{}
+	d := 0
+	for i := 0; i <= len(acn.transactions); i++ {
+		// Declines after the previous authorized transaction go first.
+		for ; declined && d < len(acn.declines) && acn.declines[d].after <= i; d++ {
+			e := acn.declines[d].entry
+			e.Violations = append([]int{}, e.Violations...)
+			entries = append(entries, e)
+		}
+		if i == len(acn.transactions) {
+			break
+		}
+
+		e := Entry{Transaction: *acn.transactions[i], Decision: Approved, Violations: []int{}}
+		// Accounts built with a history have no outcomes.
+		if i < len(acn.outcomes) {
+			e.Decision = acn.outcomes[i].decision
+			e.Violations = append(e.Violations, acn.outcomes[i].violations...)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
@@ -0,0 +1,99 @@
+// ********************************************************************
+// * history_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the account     *
+// * history iterator and its filters.                                *
+// *                                                                  *
+// * Usage: go test -v -run History ./account                         *
+// ********************************************************************
+
+package account
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// thistoryAccount - Returns an account with an approved, a flagged and,
+// a declined transaction, and an approved one with an id.
+func thistoryAccount() *Account {
+	acn, _ := (*Account)(nil).Init(true, 100)
+	rules := DefaultRules()
+	rules.Actions = map[int]string{6: Flag}
+	acn.SetRules(rules)
+	for _, tsn := range []*Transaction{
+		{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"},
+		{Merchant: "Burger King", Amount: 30, Time: "2019-02-13T10:05:00.000Z"},
+		{Merchant: "Habbib's", Amount: 500, Time: "2019-02-13T10:10:00.000Z"},
+		{ID: "t4", Merchant: "Burger Queen", Amount: 10, Time: "2019-02-13T10:15:00.000Z"},
+	} {
+		acn.ApplyTransaction(tsn)
+	}
+
+	return acn
+}
+
+// thistoryMerchants - Returns the merchants of the entries iterated.
+func thistoryMerchants(acn *Account, f Filter) []string {
+	merchants := []string{}
+	for e := range acn.History(f) {
+		merchants = append(merchants, e.Merchant)
+	}
+
+	return merchants
+}
+
+// Test the history has the authorized transactions, with their outcome.
+func TestHistoryEntries(t *testing.T) {
+	acn := thistoryAccount()
+	entries := []Entry{}
+	for e := range acn.History(Filter{}) {
+		entries = append(entries, e)
+	}
+
+	assert.Equal(t, []Entry{
+		{Transaction: Transaction{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"}, Decision: Approved, Violations: []int{}},
+		{Transaction: Transaction{Merchant: "Burger King", Amount: 30, Time: "2019-02-13T10:05:00.000Z"}, Decision: ApprovedWithFlags, Violations: []int{6}},
+		{Transaction: Transaction{ID: "t4", Merchant: "Burger Queen", Amount: 10, Time: "2019-02-13T10:15:00.000Z"}, Decision: Approved, Violations: []int{}},
+	}, entries)
+}
+
+// Test the history filters.
+func TestHistoryFilter(t *testing.T) {
+	acn := thistoryAccount()
+	at := func(value string) time.Time {
+		t, _ := time.Parse(time.RFC3339, value)
+		return t
+	}
+
+	assert := assert.New(t)
+	assert.Equal([]string{"Burger Queen", "Burger Queen"}, thistoryMerchants(acn, Filter{Merchant: "Burger Queen"}))
+	assert.Equal([]string{"Burger King"}, thistoryMerchants(acn, Filter{Outcome: ApprovedWithFlags}))
+	assert.Empty(thistoryMerchants(acn, Filter{Outcome: Declined}))
+	assert.Equal([]string{"Burger King", "Burger Queen"}, thistoryMerchants(acn, Filter{From: at("2019-02-13T10:05:00Z")}))
+	assert.Equal([]string{"Burger Queen", "Burger King"}, thistoryMerchants(acn, Filter{To: at("2019-02-13T10:05:00Z")}))
+	assert.Equal([]string{"Burger Queen"}, thistoryMerchants(acn, Filter{
+		From:     at("2019-02-13T10:01:00Z"),
+		To:       at("2019-02-13T10:20:00Z"),
+		Merchant: "Burger Queen",
+	}))
+}
+
+// Test the iteration stops when asked, and does not lock the account.
+func TestHistoryBreak(t *testing.T) {
+	acn := thistoryAccount()
+	n := 0
+	for range acn.History(Filter{}) {
+		// The account can be changed meanwhile.
+		acn.ApplyTransaction(&Transaction{Merchant: "Subway", Amount: 1, Time: "2019-02-13T11:00:00.000Z"})
+		n++
+		break
+	}
+
+	assert.Equal(t, 1, n)
+	assert.Len(t, thistoryMerchants(acn, Filter{Merchant: "Subway"}), 1)
+	assert.Empty(t, thistoryMerchants(nil, Filter{}))
+}
@@ -0,0 +1,210 @@
+// ********************************************************************
+// * property_test.go                                                 *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Property based tests, random accounts configurations and         *
+// * transactions sequences are generated to check the invariants     *
+// * every account must keep whatever transactions are applied.       *
+// *                                                                  *
+// * Usage: go test -v -run Property ./account                        *
+// ********************************************************************
+
+package account
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// Merchants used to generate transactions, with the globally blocked one.
+var tmerchants = []string{
+	"Burger King",
+	"Burger Queen",
+	"Habbib's",
+	"Fulanito",
+	"Menganito",
+}
+
+// Start time of the generated transactions.
+var tstart = time.Date(2019, 2, 13, 10, 0, 0, 0, time.UTC)
+
+// scenario - random account configuration and transactions to apply.
+type scenario struct {
+	Active       bool
+	Limit        int
+	Rules        *Rules
+	Allowed      []string
+	Denied       []string
+	Transactions []*Transaction
+}
+
+// Generate - Returns a random scenario, to be used by testing/quick.
+func (scenario) Generate(r *rand.Rand, size int) reflect.Value {
+	rules := DefaultRules()
+	rules.Interval = 1 + r.Intn(5)
+	rules.MaxTransactions = 1 + r.Intn(5)
+	rules.AmountInterval = 1 + r.Intn(5)
+	if r.Intn(2) == 0 {
+		rules.MaxAmount = r.Intn(200)
+	}
+	rules.SuspectDoubled = r.Intn(2) == 0
+
+	sc := scenario{
+		// Most of the accounts are active to apply transactions.
+		Active:  r.Intn(5) > 0,
+		Limit:   r.Intn(1000),
+		Rules:   rules,
+		Allowed: tsubset(r),
+		Denied:  tsubset(r),
+	}
+
+	for i := 0; i < size; i++ {
+		// Few amounts and merchants, to get doubled transactions.
+		sc.Transactions = append(sc.Transactions, &Transaction{
+			Merchant: tmerchants[r.Intn(len(tmerchants))],
+			Amount:   r.Intn(10) * 10,
+			Time: tstart.Add(
+				time.Duration(r.Intn(30*60)) * time.Second,
+			).Format(time.RFC3339),
+		})
+	}
+
+	return reflect.ValueOf(sc)
+}
+
+// tsubset - Returns a random subset of the merchants, mostly empty.
+func tsubset(r *rand.Rand) []string {
+	subset := []string{}
+	if r.Intn(3) > 0 {
+		return subset
+	}
+	for _, m := range tmerchants {
+		if r.Intn(3) == 0 {
+			subset = append(subset, m)
+		}
+	}
+
+	return subset
+}
+
+// apply - Returns the account after applying the scenario transactions,
+// and the transactions that were approved.
+func (sc scenario) apply() (*Account, []*Transaction) {
+	var acn *Account
+	acn, _ = acn.Init(sc.Active, sc.Limit)
+	acn.SetRules(sc.Rules)
+	acn.ApplyUpdate(&Update{
+		AllowedMerchants: sc.Allowed,
+		DeniedMerchants:  sc.Denied,
+	})
+
+	approved := []*Transaction{}
+	for _, tsn := range sc.Transactions {
+		if len(acn.ApplyTransaction(tsn)) == 0 {
+			approved = append(approved, tsn)
+		}
+	}
+
+	return acn, approved
+}
+
+// minutes - Returns the minutes from $a to $b transactions times.
+func minutes(a *Transaction, b *Transaction) float64 {
+	t1, _ := time.Parse(time.RFC3339, a.Time)
+	t2, _ := time.Parse(time.RFC3339, b.Time)
+	return t2.Sub(t1).Minutes()
+}
+
+// check - Runs a property over generated scenarios.
+func check(t *testing.T, property func(sc scenario) bool) {
+	config := &quick.Config{MaxCount: 500}
+	if err := quick.Check(property, config); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test approved amounts are the ones taken from the account limit.
+func TestPropertyLimit(t *testing.T) {
+	check(t, func(sc scenario) bool {
+		acn, approved := sc.apply()
+		spent := 0
+		for _, tsn := range approved {
+			spent += tsn.Amount
+		}
+
+		return sc.Limit-acn.Limit() == spent && acn.Limit() >= 0
+	})
+}
+
+// Test no more than $MaxTransactions are approved inside any window,
+// neither more than $MaxAmount is spent.
+func TestPropertyFrequency(t *testing.T) {
+	check(t, func(sc scenario) bool {
+		_, approved := sc.apply()
+		for _, a := range approved {
+			count := 0
+			spent := 0
+			for _, b := range approved {
+				diff := minutes(a, b)
+				if diff >= 0 && diff <= float64(sc.Rules.Interval) {
+					count++
+				}
+				if diff >= 0 && diff <= float64(sc.Rules.AmountInterval) {
+					spent += b.Amount
+				}
+			}
+			if count > sc.Rules.MaxTransactions {
+				return false
+			}
+			if sc.Rules.MaxAmount > 0 && spent > sc.Rules.MaxAmount {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// Test no two doubled transactions are approved inside the window.
+func TestPropertyDoubled(t *testing.T) {
+	check(t, func(sc scenario) bool {
+		_, approved := sc.apply()
+		for i, a := range approved {
+			for _, b := range approved[i+1:] {
+				diff := minutes(a, b)
+				if a.Merchant == b.Merchant && a.Amount == b.Amount &&
+					diff >= -float64(sc.Rules.Interval) &&
+					diff <= float64(sc.Rules.Interval) {
+					return false
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+// Test blocked merchants, globally or by the account, are never approved,
+// neither any transaction on a not active account.
+func TestPropertyBlockedMerchants(t *testing.T) {
+	check(t, func(sc scenario) bool {
+		_, approved := sc.apply()
+		if !sc.Active && len(approved) > 0 {
+			return false
+		}
+		for _, tsn := range approved {
+			if slices.Contains(blockedlist, tsn.Merchant) ||
+				slices.Contains(sc.Denied, tsn.Merchant) ||
+				(len(sc.Allowed) > 0 && !slices.Contains(sc.Allowed, tsn.Merchant)) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
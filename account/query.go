@@ -0,0 +1,98 @@
+// ********************************************************************
+// * query.go                                                         *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds card lock, JR                                    *
+// * 2026-10-19 Adds card state and expiry, JR                        *
+// *                                                                  *
+// * Read-only query of an account state, its limit and card, the     *
+// * amount held by the approved transactions and the recent window   *
+// * of its history, the account is never changed.                    *
+// *                                                                  *
+// * Usage:                                                           *
+// * summary, violations := acn.Query(&account.Query{Window: 10})     *
+// ********************************************************************
+
+package account
+
+import (
+	"time"
+)
+
+// Query - represents the history window of an account query, the $Window,
+// minutes up to $Time. The time is the last transaction one when not set,
+// the window the rules $Interval when 0, and the whole history when negative,
+// or when the time is not valid.
+type Query struct {
+	Time   string `json:"time,omitempty"`
+	Window int    `json:"window,omitempty"`
+}
+
+// Summary - Account state returned by a query, with the amount held by,
+// the approved transactions and those within the window, oldest first.
+type Summary struct {
+	Active  bool
+	Card    string
+	Expiry  string
+	Locked  bool
+	Limit   int
+	Held    int
+	History []Transaction
+}
+
+// Query - Returns the account summary with the history window of the query,
+// and an integer array with violation codes found, without changing it.
+func (acn *Account) Query(q *Query) (Summary, []int) {
+	violations := []int{}
+	// Nothing to query on an account that does not exist.
+	if !acn.Initialized() {
+		violations = append(violations, 0)
+		return Summary{History: []Transaction{}}, violations
+	}
+
+	acn.mu.Lock()
+	defer acn.mu.Unlock()
+	summary := Summary{
+		Active:  acn.card == CardActive,
+		Card:    acn.card,
+		Expiry:  acn.expiry,
+		Locked:  acn.locked,
+		Limit:   acn.limit,
+		History: []Transaction{},
+	}
+	for _, tsn := range acn.transactions {
+		summary.Held += tsn.Amount
+	}
+
+	window := q.Window
+	if window == 0 {
+		window = acn.currentRules().Interval
+	}
+	end, ok := acn.queryTime(q)
+	for _, tsn := range acn.transactions {
+		if window > 0 && ok {
+			t, _ := time.Parse(time.RFC3339, tsn.Time)
+			if t.After(end) || end.Sub(t).Minutes() > float64(window) {
+				continue
+			}
+		}
+		summary.History = append(summary.History, *tsn)
+	}
+
+	return summary, violations
+}
+
+// queryTime - Returns the end of the query window, false if there is,
+// none, as the history is empty. The lock must be held.
+func (acn *Account) queryTime(q *Query) (time.Time, bool) {
+	value := q.Time
+	if value == "" {
+		if len(acn.transactions) == 0 {
+			return time.Time{}, false
+		}
+		value = acn.transactions[len(acn.transactions)-1].Time
+	}
+	t, err := time.Parse(time.RFC3339, value)
+
+	return t, err == nil
+}
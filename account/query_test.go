@@ -0,0 +1,90 @@
+// ********************************************************************
+// * query_test.go                                                    *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the read-only   *
+// * account query.                                                   *
+// *                                                                  *
+// * Usage: go test -v -run Query ./account                           *
+// ********************************************************************
+
+package account
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// tqueryAccount - Returns an account with approved transactions at 10:00,
+// 10:01, 10:05 and a declined one.
+func tqueryAccount() *Account {
+	acn, _ := (*Account)(nil).Init(true, 100)
+	for _, tsn := range []*Transaction{
+		{Merchant: "Burger Queen", Amount: 20, Time: "2019-02-13T10:00:00.000Z"},
+		{Merchant: "Habbib's", Amount: 30, Time: "2019-02-13T10:01:00.000Z"},
+		{Merchant: "McDonald's", Amount: 10, Time: "2019-02-13T10:05:00.000Z"},
+		{Merchant: "Subway", Amount: 500, Time: "2019-02-13T10:06:00.000Z"},
+	} {
+		acn.ApplyTransaction(tsn)
+	}
+
+	return acn
+}
+
+// Test the query state and default window, the rules interval up to,
+// the last transaction.
+func TestQueryDefault(t *testing.T) {
+	acn := tqueryAccount()
+	summary, violations := acn.Query(&Query{})
+
+	assert := assert.New(t)
+	assert.Empty(violations)
+	assert.True(summary.Active)
+	assert.Equal(40, summary.Limit)
+	assert.Equal(60, summary.Held)
+	assert.Equal([]Transaction{
+		{Merchant: "McDonald's", Amount: 10, Time: "2019-02-13T10:05:00.000Z"},
+	}, summary.History)
+}
+
+// Test the query window and time.
+func TestQueryWindow(t *testing.T) {
+	acn := tqueryAccount()
+	assert := assert.New(t)
+
+	summary, _ := acn.Query(&Query{Window: 10})
+	assert.Len(summary.History, 3)
+	summary, _ = acn.Query(&Query{Time: "2019-02-13T10:01:30.000Z", Window: 1})
+	assert.Equal([]Transaction{
+		{Merchant: "Habbib's", Amount: 30, Time: "2019-02-13T10:01:00.000Z"},
+	}, summary.History)
+	summary, _ = acn.Query(&Query{Time: "2019-02-13T09:00:00.000Z", Window: 10})
+	assert.Empty(summary.History)
+	assert.NotNil(summary.History)
+	summary, _ = acn.Query(&Query{Time: "2019-02-13T09:00:00.000Z", Window: -1})
+	assert.Len(summary.History, 3)
+	// The held amount is not limited by the window.
+	assert.Equal(60, summary.Held)
+}
+
+// Test the query does not change the account.
+func TestQueryReadOnly(t *testing.T) {
+	acn := tqueryAccount()
+	summary, _ := acn.Query(&Query{Window: -1})
+	summary.History[0].Amount = 1000
+
+	assert := assert.New(t)
+	assert.Equal(40, acn.Limit())
+	again, _ := acn.Query(&Query{Window: -1})
+	assert.Equal(20, again.History[0].Amount)
+	assert.Equal([]int{}, acn.ApplyTransaction(&Transaction{Merchant: "Subway", Amount: 40, Time: "2019-02-13T10:30:00.000Z"}))
+}
+
+// Test the query of an account not initialized.
+func TestQueryNotInitialized(t *testing.T) {
+	var acn *Account
+	summary, violations := acn.Query(&Query{})
+	assert.Equal(t, []int{0}, violations)
+	assert.Empty(t, summary.History)
+}
@@ -0,0 +1,238 @@
+// ********************************************************************
+// * rules.go                                                         *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds near-duplicate check thresholds, JR              *
+// * 2026-10-19 Adds rule actions and risk scores, JR                 *
+// * 2026-10-19 Adds json fields to load rules from files, JR         *
+// * 2026-10-19 Adds rules version, JR                                *
+// * 2026-10-19 Adds too many declines thresholds, JR                 *
+// * 2026-10-19 Declines the card states violations, JR               *
+// *                                                                  *
+// * Holds the configurable thresholds used by the account to         *
+// * evaluate transactions, and to decide its outcome.                *
+// *                                                                  *
+// * Usage:                                                           *
+// * rules := account.DefaultRules()                                  *
+// * acn.SetRules(rules)                                              *
+// * decision, score := rules.Decide(violations)                      *
+// ********************************************************************
+
+package account
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// Number of minutes - default time window for the declined attempts check.
+const declineInterval = 60
+
+// Actions taken when a violation is found.
+const (
+	Decline = "decline"
+	Flag    = "flag"
+	Review  = "review"
+)
+
+// Decisions for a transaction, flagged transactions are still approved.
+const (
+	Approved          = "approved"
+	ApprovedWithFlags = "approved-with-flags"
+	Declined          = "declined"
+)
+
+// hardViolations - Violation codes always declined, whatever action is set,
+// since the transaction can't be applied to the account.
+var hardViolations = map[int]bool{
+	0:  true,
+	1:  true,
+	2:  true,
+	3:  true,
+	11: true,
+	12: true,
+	13: true,
+	14: true,
+	15: true,
+	16: true,
+	17: true,
+	18: true,
+	19: true,
+}
+
+// defaultScores - Risk score added by each violation code.
+var defaultScores = map[int]int{
+	3:  20,
+	4:  50,
+	5:  30,
+	6:  100,
+	7:  100,
+	8:  60,
+	9:  40,
+	10: 30,
+}
+
+// Rules - thresholds used by the account checks, time windows are given,
+// in minutes.
+type Rules struct {
+	// Time window for doubled and high frequency checks.
+	Interval int `json:"interval"`
+	// Max number of transactions allowed in $Interval.
+	MaxTransactions int `json:"max-transactions"`
+	// Time window for the spent amount check.
+	AmountInterval int `json:"amount-interval"`
+	// Max amount allowed to spend in $AmountInterval, 0 disables the check.
+	MaxAmount int `json:"max-amount"`
+	// Enables the near-duplicate (suspected doubled) check in $Interval.
+	SuspectDoubled bool `json:"suspect-doubled"`
+	// Max amount difference to consider two transactions near-duplicated.
+	AmountTolerance int `json:"amount-tolerance"`
+	// Whether $AmountTolerance is a percent of the previous amount.
+	TolerancePercent bool `json:"tolerance-percent"`
+	// Min merchant names similarity, from 0 to 1, to consider two,
+	// transactions near-duplicated.
+	MerchantSimilarity float64 `json:"merchant-similarity"`
+	// Action taken by violation code, decline when not set.
+	Actions map[int]string `json:"actions"`
+	// Risk score added by violation code, the default one when not set.
+	Scores map[int]int `json:"scores"`
+	// Aggregate risk score from which a flagged transaction is declined,
+	// 0 disables it.
+	MaxScore int `json:"max-score"`
+	// Declined attempts in $DeclineInterval locking the card, 0 disables,
+	// the check.
+	MaxDeclines int `json:"max-declines"`
+	// Time window for the declined attempts check.
+	DeclineInterval int `json:"decline-interval"`
+}
+
+// DefaultRules - Returns the rules used when an account has none set.
+func DefaultRules() *Rules {
+	return &Rules{
+		Interval:        maxTimeDiff,
+		MaxTransactions: maxTransactions,
+		AmountInterval:  maxTimeDiff,
+		MaxAmount:       0,
+		// Near-duplicate check is disabled, but ready to be enabled with,
+		// 5% of amount tolerance and 80% of merchant similarity.
+		SuspectDoubled:     false,
+		AmountTolerance:    5,
+		TolerancePercent:   true,
+		MerchantSimilarity: 0.8,
+		// Every violation declines the transaction.
+		Actions:  map[int]string{},
+		Scores:   map[int]int{},
+		MaxScore: 0,
+		// Cards are never locked by declines, but ready to be locked,
+		// within an hour.
+		MaxDeclines:     0,
+		DeclineInterval: declineInterval,
+	}
+}
+
+// Version - Returns an id of the rules configuration, the same rules,
+// always have the same version, nil rules have the default ones version.
+func (r *Rules) Version() string {
+	if r == nil {
+		r = DefaultRules()
+	}
+
+	// Maps are encoded sorted by key.
+	b, _ := json.Marshal(r)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Action - Returns the action taken when the violation $code is found.
+func (r *Rules) Action(code int) string {
+	if hardViolations[code] || r.Actions[code] == "" {
+		return Decline
+	}
+
+	return r.Actions[code]
+}
+
+// Score - Returns the risk score added when the violation $code is found.
+func (r *Rules) Score(code int) int {
+	if score, ok := r.Scores[code]; ok {
+		return score
+	}
+
+	return defaultScores[code]
+}
+
+// Decide - Returns the decision and aggregate risk score for a transaction,
+// with the violations found.
+func (r *Rules) Decide(violations []int) (string, int) {
+	decision := Approved
+	score := 0
+	for _, v := range violations {
+		score += r.Score(v)
+		if r.Action(v) == Decline {
+			decision = Declined
+		} else if decision == Approved {
+			decision = ApprovedWithFlags
+		}
+	}
+
+	// Too many flags decline the transaction as well.
+	if r.MaxScore > 0 && score >= r.MaxScore {
+		decision = Declined
+	}
+
+	return decision, score
+}
+
+// amountsNear - Returns true if the amount $b is within the tolerance,
+// of the amount $a.
+func (r *Rules) amountsNear(a int, b int) bool {
+	diff := math.Abs(float64(a - b))
+	if r.TolerancePercent {
+		return diff <= math.Abs(float64(a))*float64(r.AmountTolerance)/100
+	}
+
+	return diff <= float64(r.AmountTolerance)
+}
+
+// merchantsNear - Returns true if merchant names are similar enough,
+// case and surrounding spaces are ignored.
+func (r *Rules) merchantsNear(a string, b string) bool {
+	return similarity(a, b) >= r.MerchantSimilarity
+}
+
+// similarity - Returns how similar two strings are, from 0 (different),
+// to 1 (equals) based in their levenshtein distance.
+func similarity(a string, b string) float64 {
+	ra := []rune(strings.ToLower(strings.TrimSpace(a)))
+	rb := []rune(strings.ToLower(strings.TrimSpace(b)))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	// Keep only the previous row of the distances matrix.
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,303 @@
+// ********************************************************************
+// * audit.go                                                         *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Chains records only once they are written, JR         *
+// *                                                                  *
+// * Package responsible of the audit log, a json lines record of     *
+// * every operation decision, apart from the protocol output. Each   *
+// * record holds the hash of the previous one, so changing,          *
+// * removing or reordering records breaks the chain, and it is       *
+// * detected by Verify. Files are rotated once they reach a size.    *
+// *                                                                  *
+// * Usage:                                                           *
+// * log, err := audit.Open(path, maxSize)                            *
+// * log.Write(record)                                                *
+// * n, err := audit.VerifyFiles(audit.Files(path)...)                *
+// ********************************************************************
+
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBroken - The log has a record partially written, records written,
+// after it would not be read, so the log takes no more.
+var ErrBroken = errors.New("audit log broken by a record partially written")
+
+// Record - an operation decision, Seq, Time, Prev and Hash are set by,
+// the log. Limits are nil while the account is not initialized.
+type Record struct {
+	Seq           int      `json:"seq"`
+	Time          string   `json:"time"`
+	InputHash     string   `json:"input-hash"`
+	AccountID     string   `json:"account-id"`
+	Operation     string   `json:"operation"`
+	TransactionID string   `json:"transaction-id,omitempty"`
+	Replayed      bool     `json:"replayed,omitempty"`
+	Decision      string   `json:"decision,omitempty"`
+	Violations    []string `json:"violations"`
+	LimitBefore   *int     `json:"limit-before"`
+	LimitAfter    *int     `json:"limit-after"`
+	RulesVersion  string   `json:"rules-version"`
+	Prev          string   `json:"prev"`
+	Hash          string   `json:"hash"`
+}
+
+// Log - Holds where the records are written, and the last one's sequence,
+// and hash to chain the next one, and the error that broke it, if any.
+// It is safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	w       io.Writer
+	file    *os.File
+	path    string
+	size    int64
+	maxSize int64
+	seq     int
+	prev    string
+	broken  error
+	now     func() time.Time
+}
+
+// New - Returns a log writing to $w, starting a new chain.
+func New(w io.Writer) *Log {
+	return &Log{w: w, now: time.Now}
+}
+
+// Open - Returns a log appending to the file in $path, continuing the chain,
+// of its last record. Once the file reaches $maxSize bytes it is renamed,
+// to "$path.N", and a new one is started, 0 never rotates it.
+func Open(path string, maxSize int64) (*Log, error) {
+	l := &Log{path: path, maxSize: maxSize, now: time.Now}
+	files := Files(path)
+	for i := len(files) - 1; i >= 0; i-- {
+		last, err := lastRecord(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq = last.Seq
+			l.prev = last.Hash
+			break
+		}
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetClock - Changes the clock used for the records time.
+func (l *Log) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+// Write - Chains and writes the record as a json line. A record not,
+// written is not chained, and one partially written breaks the log.
+func (l *Log) Write(r *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.broken != nil {
+		return l.broken
+	}
+	if l.file != nil && l.maxSize > 0 && l.size >= l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	r.Seq = l.seq + 1
+	r.Time = l.now().UTC().Format(time.RFC3339Nano)
+	r.Prev = l.prev
+	r.Hash = hash(r)
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	n, err := l.w.Write(append(line, '\n'))
+	l.size += int64(n)
+	if err != nil {
+		if n > 0 {
+			l.broken = fmt.Errorf("%w: %v", ErrBroken, err)
+		}
+		return err
+	}
+	l.seq = r.Seq
+	l.prev = r.Hash
+	return nil
+}
+
+// Close - Closes the log file, if any.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
+
+// open - Opens the log file to append records.
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file = f
+	l.w = f
+	l.size = info.Size()
+	return nil
+}
+
+// rotate - Renames the log file to the next "$path.N" and opens a new one.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	next := len(Files(l.path))
+	if err := os.Rename(l.path, fmt.Sprintf("%s.%d", l.path, next)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Files - Returns the files of the log in $path, the rotated ones in,
+// order, and then the current one, if they exist.
+func Files(path string) []string {
+	rotated, _ := filepath.Glob(path + ".*")
+	index := map[string]int{}
+	files := []string{}
+	for _, f := range rotated {
+		n, err := strconv.Atoi(strings.TrimPrefix(f, path+"."))
+		if err != nil || n < 1 {
+			continue
+		}
+		index[f] = n
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return index[files[i]] < index[files[j]]
+	})
+
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// Verify - Checks the chain of the records read, starting after the hash,
+// $prev ("" for a new chain) and the sequence $seq. Returns the last,
+// record, nil if there is none, and the first error found.
+func Verify(r io.Reader, prev string, seq int) (*Record, error) {
+	var last *Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		rec := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return last, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch {
+		case rec.Seq != seq+1:
+			return last, fmt.Errorf("line %d: expected seq %d, got %d", line, seq+1, rec.Seq)
+		case rec.Prev != prev:
+			return last, fmt.Errorf("line %d: seq %d is not chained to the previous record", line, rec.Seq)
+		case rec.Hash != hash(rec):
+			return last, fmt.Errorf("line %d: seq %d hash does not match its content", line, rec.Seq)
+		}
+		last = rec
+		prev = rec.Hash
+		seq = rec.Seq
+	}
+
+	return last, scanner.Err()
+}
+
+// VerifyFiles - Checks the chain across the files, in order, from a new,
+// chain. Returns the number of records checked and the first error found.
+func VerifyFiles(files ...string) (int, error) {
+	prev := ""
+	seq := 0
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return seq, err
+		}
+		last, err := Verify(f, prev, seq)
+		f.Close()
+		if err != nil {
+			return seq, fmt.Errorf("%s: %w", name, err)
+		}
+		if last != nil {
+			prev = last.Hash
+			seq = last.Seq
+		}
+	}
+
+	return seq, nil
+}
+
+// Hash - Returns the sha256 hex of $b, used to hash the operations input.
+func Hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hash - Returns the hash of the record, with the previous one's hash,
+// and its own content.
+func hash(r *Record) string {
+	c := *r
+	c.Hash = ""
+	b, _ := json.Marshal(&c)
+	return Hash(append([]byte(r.Prev+"\n"), b...))
+}
+
+// lastRecord - Returns the last record in the file, nil if it is empty,
+// or it does not exist.
+func lastRecord(name string) (*Record, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if lines[len(lines)-1] == "" {
+		return nil, nil
+	}
+	rec := &Record{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), rec); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rec, nil
+}
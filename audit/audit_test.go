@@ -0,0 +1,196 @@
+// ********************************************************************
+// * audit_test.go                                                    *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds failed writes tests, JR                          *
+// *                                                                  *
+// * This file contains all unit testing related with the audit log,  *
+// * its chain, rotation and tampering detection.                     *
+// *                                                                  *
+// * Usage: go test -v ./audit                                        *
+// ********************************************************************
+
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tclock - Returns a clock moving a second each call.
+func tclock() func() time.Time {
+	now := time.Date(2019, 2, 13, 10, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+// trecord - Returns a transaction record with the limits.
+func trecord(before int, after int) *Record {
+	return &Record{
+		InputHash:    Hash([]byte(fmt.Sprint(before))),
+		AccountID:    "a1",
+		Operation:    "transaction",
+		Decision:     "approved",
+		Violations:   []string{},
+		LimitBefore:  &before,
+		LimitAfter:   &after,
+		RulesVersion: "v1",
+	}
+}
+
+// twrite - Returns the json lines of $n chained records.
+func twrite(t *testing.T, n int) string {
+	out := &bytes.Buffer{}
+	log := New(out)
+	log.SetClock(tclock())
+	for i := 0; i < n; i++ {
+		if err := log.Write(trecord(100-i*10, 90-i*10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return out.String()
+}
+
+// tfailing - Writer failing the writes after $fail, writing only $partial,
+// bytes of the failed ones.
+type tfailing struct {
+	bytes.Buffer
+	fail    bool
+	partial int
+}
+
+// Write - Writes $b, or $partial bytes of it and an error if $fail.
+func (w *tfailing) Write(b []byte) (int, error) {
+	if w.fail {
+		n, _ := w.Buffer.Write(b[:w.partial])
+		return n, errors.New("disk full")
+	}
+
+	return w.Buffer.Write(b)
+}
+
+// Test records are chained, and the chain is verified.
+func TestAuditChain(t *testing.T) {
+	out := twrite(t, 3)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert := assert.New(t)
+	assert.Len(lines, 3, "Expected a line by record.")
+	assert.Contains(lines[0], `"seq":1,"time":"2019-02-13T10:00:01Z"`)
+	assert.Contains(lines[0], `"prev":""`)
+
+	last, err := Verify(strings.NewReader(out), "", 0)
+	assert.NoError(err)
+	assert.Equal(3, last.Seq, "Expected 3 records.")
+	assert.Equal(70, *last.LimitAfter)
+}
+
+// Test changed, removed and reordered records are detected.
+func TestAuditTampering(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(twrite(t, 3)), "\n")
+	tampered := map[string][]string{
+		"Changed": {
+			lines[0],
+			strings.Replace(lines[1], `"limit-after":80`, `"limit-after":85`, 1),
+			lines[2],
+		},
+		"Removed":   {lines[0], lines[2]},
+		"Reordered": {lines[0], lines[2], lines[1]},
+		"Malformed": {lines[0], lines[1][1:], lines[2]},
+	}
+
+	for name, records := range tampered {
+		t.Run(name, func(t *testing.T) {
+			last, err := Verify(strings.NewReader(strings.Join(records, "\n")), "", 0)
+			assert := assert.New(t)
+			assert.Error(err, "Expected the tampering detected.")
+			assert.Contains(err.Error(), "line 2")
+			assert.Equal(1, last.Seq, "Expected the first record verified.")
+		})
+	}
+}
+
+// Test a changed record, with its hash computed again, breaks the chain.
+func TestAuditRehashedTampering(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(twrite(t, 3)), "\n")
+	out := &bytes.Buffer{}
+	log := New(out)
+	log.SetClock(tclock())
+	log.Write(trecord(100, 0))
+
+	_, err := Verify(strings.NewReader(strings.Join([]string{strings.TrimSpace(out.String()), lines[1]}, "\n")), "", 0)
+	assert.Error(t, err, "Expected the next record not chained.")
+}
+
+// Test the log file rotation, and the chain continued when reopened.
+func TestAuditRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	assert := assert.New(t)
+
+	log, err := Open(path, 1)
+	assert.NoError(err)
+	log.SetClock(tclock())
+	for i := 0; i < 3; i++ {
+		assert.NoError(log.Write(trecord(100, 90)))
+	}
+	assert.NoError(log.Close())
+	assert.Equal([]string{path + ".1", path + ".2", path}, Files(path))
+
+	// Reopened logs continue the chain.
+	log, err = Open(path, 0)
+	assert.NoError(err)
+	assert.NoError(log.Write(trecord(90, 80)))
+	assert.NoError(log.Close())
+
+	n, err := VerifyFiles(Files(path)...)
+	assert.NoError(err)
+	assert.Equal(4, n, "Expected 4 records verified.")
+
+	// A removed rotated file is detected.
+	assert.NoError(os.Remove(path + ".1"))
+	_, err = VerifyFiles(Files(path)...)
+	assert.Error(err, "Expected the removed file detected.")
+}
+
+// Test a record not written is not chained, the next one takes its seq.
+func TestAuditWriteFailed(t *testing.T) {
+	out := &tfailing{}
+	log := New(out)
+	log.SetClock(tclock())
+	assert := assert.New(t)
+
+	assert.NoError(log.Write(trecord(100, 90)))
+	out.fail = true
+	assert.Error(log.Write(trecord(90, 80)))
+	out.fail = false
+	assert.NoError(log.Write(trecord(90, 80)))
+
+	last, err := Verify(strings.NewReader(out.String()), "", 0)
+	assert.NoError(err)
+	assert.Equal(2, last.Seq)
+}
+
+// Test a record partially written breaks the log, no more are written.
+func TestAuditWritePartial(t *testing.T) {
+	out := &tfailing{}
+	log := New(out)
+	assert := assert.New(t)
+
+	assert.NoError(log.Write(trecord(100, 90)))
+	out.fail = true
+	out.partial = 10
+	assert.Error(log.Write(trecord(90, 80)))
+	written := out.Len()
+	out.fail = false
+	assert.ErrorIs(log.Write(trecord(90, 80)), ErrBroken)
+	assert.Equal(written, out.Len(), "Expected nothing written after the log broke.")
+}
@@ -3,35 +3,472 @@
 // *                                                                  *
 // * 2020-03-13 First Version, JR                                     *
 // * 2020-03-16 Adds Print output, JR                                 *
+// * 2026-10-19 Adds rules flags, JR                                  *
+// * 2026-10-19 Adds near-duplicate check flags, JR                   *
+// * 2026-10-19 Adds rule actions and decisions flags, JR             *
+// * 2026-10-19 Adds candidate rules shadow evaluation flags, JR      *
+// * 2026-10-19 Moves stdin loop to run, to be tested in-process, JR  *
+// * 2026-10-19 Adds gen subcommand, JR                               *
+// * 2026-10-19 Adds cpu and memory profiling flags, JR               *
+// * 2026-10-19 Adds sharded concurrent processing, JR                *
+// * 2026-10-19 Adds idempotency size flag, JR                        *
+// * 2026-10-19 Adds transaction ids flags, JR                        *
+// * 2026-10-19 Adds audit log flags and verify-audit subcommand, JR  *
+// * 2026-10-19 Adds metrics listener flag, JR                        *
+// * 2026-10-19 Adds tracing exporters flags, JR                      *
+// * 2026-10-19 Adds log level and format flags, JR                   *
+// * 2026-10-19 Adds input and output formats flags, JR               *
+// * 2026-10-19 Adds protocol version flags and schema subcommand, JR *
+// * 2026-10-19 Adds history subcommand, JR                           *
+// * 2026-10-19 Adds too many declines flags, JR                      *
+// * 2026-10-19 Exits after the cleanups, 1 on stream errors, JR      *
 // *                                                                  *
 // * Go application able to read stdin line by line and retrieve,     *
 // * the messages associated to operations read .                     *
 // *                                                                  *
 // * Usage:                                                           *
-// * $ authorizer < $FILE                                             *
+// * $ authorizer [-max-amount N -amount-interval M] < $FILE          *
+// * $ authorizer -input-format csv -output-format msgpack < $FILE    *
+// * $ authorizer gen [-accounts N -transactions M -seed S]           *
+// * $ authorizer verify-audit $AUDIT_FILE                            *
+// * $ authorizer schema [-version V] $NAME                           *
+// * $ authorizer history [-snapshot] [flags] $FILE                   *
 // ********************************************************************
 
 package main
 
 import (
+	"authorizer/account"
+	"authorizer/audit"
 	"authorizer/executer"
-	"bufio"
+	"authorizer/executer/message"
+	"authorizer/metrics"
+	"authorizer/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
 )
 
+// actionsFlag - Parses repeated "violation=action" flags.
+type actionsFlag map[int]string
+
+// String - Returns the flag value representation.
+func (f actionsFlag) String() string {
+	return fmt.Sprint(map[int]string(f))
+}
+
+// Set - Adds a violation action, from a "violation=action" value.
+func (f actionsFlag) Set(value string) error {
+	name, action, _ := strings.Cut(value, "=")
+	code, ok := account.Code(name)
+	if !ok {
+		return fmt.Errorf("unknown violation %q", name)
+	}
+	switch action {
+	case account.Decline, account.Flag, account.Review:
+		f[code] = action
+		return nil
+	}
+
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// mappingFlag - Parses repeated "column=field" flags.
+type mappingFlag map[string]string
+
+// String - Returns the flag value representation.
+func (f mappingFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+// Set - Adds a csv column mapping, from a "column=field" value.
+func (f mappingFlag) Set(value string) error {
+	column, field, ok := strings.Cut(value, "=")
+	if !ok || column == "" || field == "" {
+		return fmt.Errorf("expected column=field, got %q", value)
+	}
+	f[column] = field
+
+	return nil
+}
+
+// scoresFlag - Parses repeated "violation=score" flags.
+type scoresFlag map[int]int
+
+// String - Returns the flag value representation.
+func (f scoresFlag) String() string {
+	return fmt.Sprint(map[int]int(f))
+}
+
+// Set - Adds a violation score, from a "violation=score" value.
+func (f scoresFlag) Set(value string) error {
+	name, score, _ := strings.Cut(value, "=")
+	code, ok := account.Code(name)
+	if !ok {
+		return fmt.Errorf("unknown violation %q", name)
+	}
+	n, err := strconv.Atoi(score)
+	if err != nil {
+		return err
+	}
+	f[code] = n
+
+	return nil
+}
+
 func main() {
+	// Subcommands.
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		os.Exit(gen(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		os.Exit(verifyAudit(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(schema(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		os.Exit(history(os.Args[2:], os.Stdout))
+	}
+
+	os.Exit(authorize())
+}
+
+// authorize - Parses the flags and executes the operations of stdin, returns,
+// the exit code, after stopping the profiling and closing the outputs.
+func authorize() int {
+	// Rules are configurable by flags, defaults keep the original behaviour.
+	rules := account.DefaultRules()
+	flag.IntVar(&rules.Interval, "interval", rules.Interval,
+		"Minutes window for doubled and high frequency checks.")
+	flag.IntVar(&rules.MaxTransactions, "max-transactions", rules.MaxTransactions,
+		"Max number of transactions allowed in -interval.")
+	flag.IntVar(&rules.AmountInterval, "amount-interval", rules.AmountInterval,
+		"Minutes window for the spent amount check.")
+	flag.IntVar(&rules.MaxAmount, "max-amount", rules.MaxAmount,
+		"Max amount allowed in -amount-interval, 0 disables the check.")
+	flag.BoolVar(&rules.SuspectDoubled, "suspect-doubled", rules.SuspectDoubled,
+		"Enables the near-duplicate transactions check in -interval.")
+	flag.IntVar(&rules.AmountTolerance, "amount-tolerance", rules.AmountTolerance,
+		"Max amount difference for near-duplicate transactions.")
+	flag.BoolVar(&rules.TolerancePercent, "tolerance-percent", rules.TolerancePercent,
+		"Whether -amount-tolerance is a percent of the amount.")
+	flag.Float64Var(&rules.MerchantSimilarity, "merchant-similarity", rules.MerchantSimilarity,
+		"Min merchant similarity (0 to 1) for near-duplicate transactions.")
+	flag.Var(actionsFlag(rules.Actions), "action",
+		"Action for a violation as violation=decline|flag|review, repeatable.")
+	flag.Var(scoresFlag(rules.Scores), "score",
+		"Risk score for a violation as violation=score, repeatable.")
+	flag.IntVar(&rules.MaxScore, "max-score", rules.MaxScore,
+		"Aggregate risk score declining flagged transactions, 0 disables it.")
+	flag.IntVar(&rules.MaxDeclines, "max-declines", rules.MaxDeclines,
+		"Declined transactions in -decline-interval locking the card, 0 disables it.")
+	flag.IntVar(&rules.DeclineInterval, "decline-interval", rules.DeclineInterval,
+		"Minutes window for the declined transactions check.")
+	decisions := flag.Bool("decisions", false,
+		"Adds decision and risk score to transactions output.")
+	transactionIDs := flag.Bool("transaction-ids", false,
+		"Adds transaction id and authorization code to transactions output.")
+	idSeed := flag.Int64("id-seed", 0,
+		"Seed of the generated transaction ids and codes, 0 is random.")
+	auditFile := flag.String("audit", "",
+		"File where every operation decision is recorded, as json lines.")
+	auditMaxSize := flag.Int64("audit-max-size", 100<<20,
+		"Bytes from which the -audit file is rotated, 0 never rotates it.")
+	metricsAddr := flag.String("metrics-addr", "",
+		"Address of the /metrics http listener (e.g. :9090), off if not set.")
+	traceFile := flag.String("trace-file", "",
+		"File where the operations spans are exported, as json lines.")
+	traceEndpoint := flag.String("trace-endpoint", "",
+		"OTLP http collector url where the spans are exported (e.g. http://localhost:4318).")
+	logLevel := flag.String("log-level", "info",
+		"Level of the logs written to stderr: debug, info, warn or error.")
+	logFormat := flag.String("log-format", "text",
+		"Format of the logs written to stderr: text or json.")
+	inputFormat := flag.String("input-format", message.NDJSON,
+		"Format of the operations read from stdin: "+strings.Join(message.Formats, ", ")+".")
+	outputFormat := flag.String("output-format", message.NDJSON,
+		"Format of the outputs written to stdout: "+strings.Join(message.Formats, ", ")+".")
+	outputVersion := flag.Int("output-version", 0,
+		"Protocol version of the outputs, 0 outputs each operation in its own version.")
+	validate := flag.Bool("validate", false,
+		"Ignores the operations not valid against the schema of their version.")
+	csvMapping := mappingFlag{}
+	flag.Var(csvMapping, "csv-map",
+		"Maps a csv input header column to a message one as column=field, repeatable.")
+	candidate := flag.String("candidate", "",
+		"Json file with candidate rules evaluated in shadow.")
+	report := flag.String("shadow-report", "",
+		"File where shadow divergences are reported, stderr if not set.")
+	cpuprofile := flag.String("cpuprofile", "",
+		"Writes a cpu profile to the file.")
+	memprofile := flag.String("memprofile", "",
+		"Writes a memory profile to the file, when input ends.")
+	workers := flag.Int("workers", 1,
+		"Workers executing operations sharded by account-id, 1 is sequential.")
+	idempotency := flag.Int("idempotency-size", 0,
+		"Transaction ids kept by account to replay retries, 0 is 1000, -1 disables it.")
+	flag.Parse()
+
+	// Logs never go to stdout, it is only for the operations output.
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *outputVersion != 0 && !message.SupportedVersion(*outputVersion) {
+		fmt.Fprintf(os.Stderr, "-output-version %d is not supported\n", *outputVersion)
+		return 2
+	}
+
+	dec, enc, err := codecs(*inputFormat, *outputFormat, csvMapping, os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	stop, err := profile(*cpuprofile, *memprofile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stop()
+
+	// The audit log is shared by all the executers.
+	var auditLog *audit.Log
+	if *auditFile != "" {
+		auditLog, err = audit.Open(*auditFile, *auditMaxSize)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer auditLog.Close()
+	}
+
+	// The metrics are shared by all the executers too.
+	var m *metrics.Metrics
+	if *metricsAddr != "" {
+		m = metrics.New()
+		if _, err := serveMetrics(*metricsAddr, m); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	// The spans are exported by a provider shared by all the executers,
+	// pending spans are exported when input ends.
+	var tp *tracing.Provider
+	if *traceFile != "" || *traceEndpoint != "" {
+		tp, err = tracing.New(context.Background(), *traceFile, *traceEndpoint)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer func() {
+			if err := tp.Shutdown(context.Background()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
 	// Init our operation's execter.
-	e := executer.Init()
-	// Read stdin line by line, while not empty line.
-	stdin := bufio.NewReader(os.Stdin)
-	for {
-		op, _ := stdin.ReadString('\n')
-		if op == "" {
-			return
-		}
-		// Sent input line, and get a json output string.
-		out := e.Exec(op)
-		fmt.Println(out)
+	initExecuter := func() *executer.Executer {
+		e := executer.Init()
+		e.SetRules(rules)
+		e.SetDecisions(*decisions)
+		e.SetIdempotencySize(*idempotency)
+		e.SetTransactionIDs(*transactionIDs)
+		e.SetOutputVersion(*outputVersion)
+		e.SetValidation(*validate)
+		if *idSeed != 0 {
+			e.SetIDGenerator(executer.SeededIDs(*idSeed))
+		}
+		if auditLog != nil {
+			e.SetAudit(auditLog)
+		}
+		if m != nil {
+			e.SetMetrics(m)
+		}
+		if tp != nil {
+			e.SetTracerProvider(tp)
+		}
+		e.SetLogger(logger)
+		return e
+	}
+
+	// Each worker has its own accounts, output keeps the input order.
+	if *workers > 1 {
+		if *candidate != "" {
+			fmt.Fprintln(os.Stderr, "-candidate is not supported with -workers")
+			return 2
+		}
+		err := executer.InitSharded(*workers, initExecuter).Stream(dec, enc)
+		return exitCode(err)
+	}
+
+	e := initExecuter()
+
+	// Candidate rules evaluated in shadow, divergences go to the report.
+	if *candidate != "" {
+		crules, err := loadRules(*candidate)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		out := os.Stderr
+		if *report != "" {
+			out, err = os.Create(*report)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+			defer out.Close()
+		}
+		e.SetCandidate(crules, out)
+		defer e.WriteShadowSummary()
+	}
+
+	return run(e, dec, enc)
+}
+
+// run - Reads operations from the decoder, and writes the output of each,
+// one with the encoder, operations not recorded in the audit log are,
+// logged by the executer, decoding or encoding errors are reported,
+// to stderr. Returns the exit code, 1 if the stream failed.
+func run(e *executer.Executer, dec message.Decoder, enc message.Encoder) int {
+	return exitCode(e.Stream(context.Background(), dec, enc))
+}
+
+// exitCode - Returns the exit code of a stream error, 0 without error,
+// the errors other than the audit log ones, already logged, are reported,
+// to stderr.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	// Operations not audited are already logged.
+	if !errors.Is(err, executer.ErrAudit) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return 1
+}
+
+// codecs - Returns the decoder of $in and the encoder of $out, with the,
+// formats, the csv $mapping is only for a csv input.
+func codecs(input string, output string, mapping map[string]string, in io.Reader, out io.Writer) (message.Decoder, message.Encoder, error) {
+	var dec message.Decoder
+	var err error
+	switch {
+	case input == message.CSV:
+		dec = message.NewCSVDecoder(in, mapping)
+	case len(mapping) > 0:
+		return nil, nil, errors.New("-csv-map is only for a csv -input-format")
+	default:
+		if dec, err = message.NewDecoder(input, in); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	enc, err := message.NewEncoder(output, out)
+	if err != nil {
+		return nil, nil, err
 	}
+
+	return dec, enc, nil
+}
+
+// newLogger - Returns the logger writing to $w the records from $level,
+// (debug, info, warn or error) with the $format (text or json).
+func newLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+
+	options := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// serveMetrics - Serves the metrics on /metrics at $addr in background,
+// while the application runs, returns the address listened.
+func serveMetrics(addr string, m *metrics.Metrics) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	go http.Serve(ln, mux)
+	return ln.Addr(), nil
+}
+
+// profile - Starts the cpu profile if a file is given, and returns the,
+// function to stop it and write the memory profile if a file is given.
+func profile(cpu string, mem string) (func(), error) {
+	var cpuFile *os.File
+	if cpu != "" {
+		var err error
+		if cpuFile, err = os.Create(cpu); err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, err
+		}
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if mem != "" {
+			memFile, err := os.Create(mem)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer memFile.Close()
+			// Up to date allocations statistics.
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(memFile); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}, nil
+}
+
+// loadRules - Returns the rules read from a json file, over the defaults.
+func loadRules(file string) (*account.Rules, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	rules := account.DefaultRules()
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	return rules, nil
 }
@@ -0,0 +1,433 @@
+// ********************************************************************
+// * authorizer_test.go                                               *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds gen subcommand scenario, JR                      *
+// * 2026-10-19 Adds stdin loop benchmark, JR                         *
+// * 2026-10-19 Adds verify-audit subcommand scenario, JR             *
+// * 2026-10-19 Adds metrics listener scenario, JR                    *
+// * 2026-10-19 Adds logger flags scenario, JR                        *
+// * 2026-10-19 Adds input and output formats scenario, JR            *
+// * 2026-10-19 Adds schema subcommand scenario, JR                   *
+// * 2026-10-19 Adds history subcommand scenario, JR                  *
+// * 2026-10-19 Types csv outputs by their operation, JR              *
+// * 2026-10-19 Checks history without generated ids, JR              *
+// * 2026-10-19 Adds stdin loop exit code scenario, JR                *
+// *                                                                  *
+// * End to end (e2e) tests, each directory inside ./test is a        *
+// * scenario with an "in" file executed in-process, and an "out"     *
+// * file with the expected output to compare line by line.           *
+// *                                                                  *
+// * Usage: go test -v -run TestE2E .                                 *
+// * Regenerate "out" files: go test -run TestE2E . -update           *
+// * Benchmarks: go test -run XXX -bench . .                          *
+// ********************************************************************
+
+package main
+
+import (
+	"authorizer/audit"
+	"authorizer/executer"
+	"authorizer/executer/message"
+	"authorizer/metrics"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Rewrites the expected "out" files with the current output.
+var update = flag.Bool("update", false, "Update e2e golden out files.")
+
+// Test all scenarios inside ./test.
+func TestE2E(t *testing.T) {
+	ins, err := filepath.Glob(filepath.Join("test", "*", "in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) == 0 {
+		t.Fatal("No e2e scenarios found.")
+	}
+
+	for _, in := range ins {
+		dir := filepath.Dir(in)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			input, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := &bytes.Buffer{}
+			run(executer.Init(), message.NewNDJSONDecoder(bytes.NewReader(input)), message.NewNDJSONEncoder(result))
+
+			out := filepath.Join(dir, "out")
+			if *update {
+				if err := os.WriteFile(out, result.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, diff := range diffLines(string(expected), result.String()) {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// diffLines - Returns a description of each line that differs,
+// between the expected and the actual output.
+func diffLines(expected string, actual string) []string {
+	exp := splitLines(expected)
+	act := splitLines(actual)
+	diffs := []string{}
+	for i := 0; i < len(exp) || i < len(act); i++ {
+		switch {
+		case i >= len(act):
+			diffs = append(diffs, lineDiff(i, exp[i], "<missing>"))
+		case i >= len(exp):
+			diffs = append(diffs, lineDiff(i, "<missing>", act[i]))
+		case exp[i] != act[i]:
+			diffs = append(diffs, lineDiff(i, exp[i], act[i]))
+		}
+	}
+
+	return diffs
+}
+
+// lineDiff - Returns the description of a line that differs.
+func lineDiff(i int, expected string, actual string) string {
+	return fmt.Sprintf("line %d:\n  expected: %s\n  actual:   %s", i+1, expected, actual)
+}
+
+// splitLines - Returns the lines of a text, without the trailing new line.
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Test gen subcommand output is executed without unknown operations.
+func TestGen(t *testing.T) {
+	stream := &bytes.Buffer{}
+	if code := gen([]string{"-accounts", "2", "-transactions", "20"}, stream); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	result := &bytes.Buffer{}
+	run(executer.Init(), message.NewNDJSONDecoder(stream), message.NewNDJSONEncoder(result))
+
+	lines := splitLines(result.String())
+	if len(lines) != 22 {
+		t.Fatalf("Expected 22 output lines, got %d.", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, `{"account-id": "account-`) ||
+			strings.Contains(line, `"account": {}`) {
+			t.Errorf("Expected an account output, got %s", line)
+		}
+	}
+
+	if code := gen([]string{"-accounts", "0"}, stream); code == 0 {
+		t.Error("Expected exit code for not valid config.")
+	}
+}
+
+// Test verify-audit subcommand with a valid and a tampered audit log.
+func TestVerifyAudit(t *testing.T) {
+	stream := &bytes.Buffer{}
+	if code := gen([]string{"-transactions", "20"}, stream); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.Open(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := executer.Init()
+	e.SetAudit(log)
+	run(e, message.NewNDJSONDecoder(stream), message.NewNDJSONEncoder(io.Discard))
+	log.Close()
+
+	out := &bytes.Buffer{}
+	if code := verifyAudit([]string{path}, out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	if !strings.HasPrefix(out.String(), "21 records verified") {
+		t.Errorf("Expected 21 records verified, got %s", out.String())
+	}
+
+	// A changed limit is detected.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), `"limit-after":`, `"limit-after":1`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := verifyAudit([]string{path}, out); code != 1 {
+		t.Errorf("Expected exit code 1 for a tampered log, got %d.", code)
+	}
+	if code := verifyAudit([]string{}, out); code != 2 {
+		t.Errorf("Expected exit code 2 without file, got %d.", code)
+	}
+}
+
+// Test the metrics listener serves the metrics of the operations run.
+func TestServeMetrics(t *testing.T) {
+	m := metrics.New()
+	addr, err := serveMetrics("127.0.0.1:0", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := executer.Init()
+	e.SetMetrics(m)
+	stream := &bytes.Buffer{}
+	if code := gen([]string{"-transactions", "20"}, stream); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	run(e, message.NewNDJSONDecoder(stream), message.NewNDJSONEncoder(io.Discard))
+
+	res, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `authorizer_operations_total{operation="transaction"} 20`) {
+		t.Errorf("Expected 20 transactions counted, got:\n%s", body)
+	}
+}
+
+// Test the logs level and format, stdout only gets the operations output.
+func TestLogger(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		logs := &bytes.Buffer{}
+		logger, err := newLogger(logs, "warn", format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := executer.Init()
+		e.SetLogger(logger)
+		out := &bytes.Buffer{}
+		run(e, message.NewNDJSONDecoder(strings.NewReader(`{"account": {"active-card": tru`+"\n"+`{"account": {"active-card": true, "available-limit": 100}}`+"\n")), message.NewNDJSONEncoder(out))
+
+		if out.String() != "{\"account\": {}, \"violations\": []}\n{\"account\": {\"active-card\": true, \"available-limit\": 100}, \"violations\": []}\n" {
+			t.Errorf("Expected only the operations output, got:\n%s", out)
+		}
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		if len(lines) != 1 || !strings.Contains(lines[0], "operation ignored") {
+			t.Errorf("Expected only the %s warning of the malformed line, got:\n%s", format, logs)
+		}
+		if format == "json" && !strings.HasPrefix(lines[0], `{"time":`) {
+			t.Errorf("Expected a json record, got:\n%s", lines[0])
+		}
+	}
+
+	if _, err := newLogger(io.Discard, "verbose", "text"); err == nil {
+		t.Error("Expected an unknown level error.")
+	}
+	if _, err := newLogger(io.Discard, "debug", "xml"); err == nil {
+		t.Error("Expected an unknown format error.")
+	}
+}
+
+// failWriter - writer failing every write.
+type failWriter struct{}
+
+// Write - Fails.
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("closed")
+}
+
+// Test the stdin loop exit code, 1 if the output can not be written.
+func TestRunExitCode(t *testing.T) {
+	op := `{"account": {"active-card": true, "available-limit": 100}}` + "\n"
+	if code := run(executer.Init(), message.NewNDJSONDecoder(strings.NewReader(op)), message.NewNDJSONEncoder(io.Discard)); code != 0 {
+		t.Errorf("Expected exit code 0, got %d.", code)
+	}
+	if code := run(executer.Init(), message.NewNDJSONDecoder(strings.NewReader(op)), message.NewNDJSONEncoder(failWriter{})); code != 1 {
+		t.Errorf("Expected exit code 1 for an output error, got %d.", code)
+	}
+}
+
+// Test a csv input, with mapped columns, written as csv.
+func TestFormats(t *testing.T) {
+	mapping := mappingFlag{}
+	for _, value := range []string{"op=type", "store=merchant"} {
+		if err := mapping.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := strings.NewReader("op,active-card,available-limit,store,amount,time\n" +
+		"account,true,100,,,\n" +
+		"transaction,,,Burger Queen,120,2019-02-13T10:00:00.000Z\n")
+	out := &bytes.Buffer{}
+	dec, enc, err := codecs(message.CSV, message.CSV, mapping, in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(executer.Init(), dec, enc)
+
+	expected := strings.Join(message.CSVColumns, ",") + "\n" +
+		",account,,,true,100,,,,,,,,,,,,,,,,,,,\n" +
+		",transaction,,,true,100,,,,,,,,,,,,,insufficient-limit,,,,,,\n"
+	if out.String() != expected {
+		t.Errorf("Expected csv output:\n%s\ngot:\n%s", expected, out)
+	}
+
+	if _, _, err := codecs(message.NDJSON, message.CSV, mapping, in, out); err == nil {
+		t.Error("Expected -csv-map error for a json lines input.")
+	}
+	if _, _, err := codecs(message.NDJSON, "xml", nil, in, out); err == nil {
+		t.Error("Expected an unknown format error.")
+	}
+	if err := mapping.Set("op"); err == nil {
+		t.Error("Expected a malformed mapping error.")
+	}
+}
+
+// Test schema subcommand writes the schema of the version.
+func TestSchema(t *testing.T) {
+	out := &bytes.Buffer{}
+	if code := schema([]string{"-version", "1", "transaction"}, out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	if !strings.Contains(out.String(), `"$id": "https://github.com/juliocri/authorizer/schema/v1/transaction.json"`) {
+		t.Errorf("Expected the v1 transaction schema, got:\n%s", out)
+	}
+
+	if code := schema([]string{"-version", "3", "transaction"}, io.Discard); code != 1 {
+		t.Errorf("Expected exit code 1 for an unsupported version, got %d.", code)
+	}
+	if code := schema([]string{}, io.Discard); code != 2 {
+		t.Errorf("Expected exit code 2 without name, got %d.", code)
+	}
+}
+
+// Test history subcommand exports an event log history, and a snapshot,
+// of it, filtered.
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "events")
+	events := strings.Join([]string{
+		`{"account-id": "b", "account": {"active-card": true, "available-limit": 100}}`,
+		`{"account-id": "a", "account": {"active-card": true, "available-limit": 100}}`,
+		`{"account-id": "b", "transaction": {"id": "t1", "merchant": "Subway", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"account-id": "a", "transaction": {"id": "t2", "merchant": "Habbib's", "amount": 30, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"account-id": "a", "transaction": {"id": "t3", "merchant": "Subway", "amount": 500, "time": "2019-02-13T10:02:00.000Z"}}`,
+		`{"account-id": "a", "transaction": {"id": "t4", "merchant": "Subway", "amount": 10, "time": "2019-02-13T10:03:00.000Z"}}`,
+	}, "\n")
+	if err := os.WriteFile(log, []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if code := history([]string{log}, out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	expected := strings.Join([]string{
+		`{"account-id":"a","id":"t2","merchant":"Habbib's","amount":30,"time":"2019-02-13T10:01:00.000Z","decision":"approved","violations":[]}`,
+		`{"account-id":"a","id":"t4","merchant":"Subway","amount":10,"time":"2019-02-13T10:03:00.000Z","decision":"approved","violations":[]}`,
+		`{"account-id":"b","id":"t1","merchant":"Subway","amount":20,"time":"2019-02-13T10:00:00.000Z","decision":"approved","violations":[]}`,
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected history:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// The json export is read again as a snapshot.
+	snapshot := filepath.Join(dir, "snapshot")
+	if err := os.WriteFile(snapshot, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-account-id", "a", "-merchant", "Subway", "-format", "csv", log},
+		{"-snapshot", "-account-id", "a", "-from", "2019-02-13T10:02:00Z", "-format", "csv", snapshot},
+	} {
+		out.Reset()
+		if code := history(args, out); code != 0 {
+			t.Fatalf("Expected exit code 0 for %v, got %d.", args, code)
+		}
+		expected := "account-id,id,merchant,amount,time,decision,violations\n" +
+			"a,t4,Subway,10,2019-02-13T10:03:00.000Z,approved,\n"
+		if out.String() != expected {
+			t.Errorf("Expected csv history for %v:\n%s\ngot:\n%s", args, expected, out)
+		}
+	}
+
+	// Declined transactions are only exported if asked.
+	out.Reset()
+	if code := history([]string{"-declined", "-outcome", "declined", log}, out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	declined := `{"account-id":"a","id":"t3","merchant":"Subway","amount":500,"time":"2019-02-13T10:02:00.000Z","decision":"declined","violations":["insufficient-limit"]}` + "\n"
+	if out.String() != declined {
+		t.Errorf("Expected declined history:\n%s\ngot:\n%s", declined, out)
+	}
+
+	// Transactions without id are exported without the generated one, so,
+	// the same event log always exports the same history.
+	anonymous := filepath.Join(dir, "anonymous")
+	events = strings.Join([]string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Subway", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"merchant": "Subway", "amount": 500, "time": "2019-02-13T10:01:00.000Z"}}`,
+	}, "\n")
+	if err := os.WriteFile(anonymous, []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected = strings.Join([]string{
+		`{"account-id":"","merchant":"Subway","amount":20,"time":"2019-02-13T10:00:00.000Z","decision":"approved","violations":[]}`,
+		`{"account-id":"","merchant":"Subway","amount":500,"time":"2019-02-13T10:01:00.000Z","decision":"declined","violations":["insufficient-limit"]}`,
+	}, "\n") + "\n"
+	for i := 0; i < 2; i++ {
+		out.Reset()
+		if code := history([]string{"-declined", anonymous}, out); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d.", code)
+		}
+		if out.String() != expected {
+			t.Errorf("Expected history without generated ids:\n%s\ngot:\n%s", expected, out)
+		}
+	}
+
+	for args, code := range map[string]int{
+		"":                        2,
+		"-format xml " + log:      2,
+		"-from yesterday " + log:  2,
+		filepath.Join(dir, "nop"): 1,
+		"-snapshot " + log:        1,
+	} {
+		if got := history(strings.Fields(args), io.Discard); got != code {
+			t.Errorf("Expected exit code %d for %q, got %d.", code, args, got)
+		}
+	}
+}
+
+// Benchmark the stdin loop with a generated stream.
+func BenchmarkRun(b *testing.B) {
+	stream := &bytes.Buffer{}
+	if code := gen([]string{"-transactions", "1000", "-limit", "1000000"}, stream); code != 0 {
+		b.Fatalf("Expected exit code 0, got %d.", code)
+	}
+	input := stream.Bytes()
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		run(executer.Init(), message.NewNDJSONDecoder(bytes.NewReader(input)), message.NewNDJSONEncoder(io.Discard))
+	}
+}
@@ -0,0 +1,273 @@
+// ********************************************************************
+// * api.go                                                           *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Replays transactions by id, JR                        *
+// * 2026-10-19 Adds transaction id and authorization code, JR        *
+// * 2026-10-19 Adds audit log records, JR                            *
+// * 2026-10-19 Adds operations metrics, JR                           *
+// * 2026-10-19 Traces the transaction checks, JR                     *
+// * 2026-10-19 Logs operations decisions, JR                         *
+// * 2026-10-19 Adds read-only account query, JR                      *
+// * 2026-10-19 Adds card lock state, JR                              *
+// * 2026-10-19 Adds card state and expiry, JR                        *
+// * 2026-10-19 Tells generated ids apart in account queries, JR      *
+// * 2026-10-19 Numbers operations by input position if given, JR     *
+// * 2026-10-19 Tells declined generated ids apart, JR                *
+// *                                                                  *
+// * Typed library API of the executer, operations take a context     *
+// * and Go values instead of json lines, and return a structured     *
+// * decision and an error, Exec is an adapter over this API.         *
+// *                                                                  *
+// * Usage:                                                           *
+// * e := executer.Init()                                             *
+// * e.CreateAccount(ctx, id, executer.AccountSettings{...})          *
+// * d, err := e.Authorize(ctx, id, account.Transaction{...})         *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"authorizer/executer/message"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnknownOperation - returned for json lines with no known operation.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// ErrAudit - returned, wrapped, when an operation was run but its audit,
+// record could not be written, the decision is still returned.
+var ErrAudit = errors.New("audit log")
+
+// AccountID - identifies an account, empty while working with a single one.
+type AccountID string
+
+// AccountSettings - fields an account is created with, merchant lists,
+// and the card state and expiry are optional, the card state takes,
+// precedence over Active.
+type AccountSettings struct {
+	Active           bool
+	Limit            int
+	AllowedMerchants []string
+	DeniedMerchants  []string
+	Card             string
+	Expiry           string
+}
+
+// State - account fields right after an operation, with the card state,
+// and expiry, Locked is set while the card is locked by too many declines.
+type State struct {
+	Active bool
+	Card   string
+	Expiry string
+	Locked bool
+	Limit  int
+}
+
+// Decision - result of an operation over an account, with the violations,
+// codes found, the transaction id, decision and aggregate risk score are,
+// only set for transactions, and the authorization code for the approved,
+// ones. Account is nil while the account is not initialized, Replayed,
+// is set for retried transactions, and Summary only for account queries.
+type Decision struct {
+	AccountID         AccountID
+	Account           *State
+	Violations        []int
+	TransactionID     string
+	AuthorizationCode string
+	Decision          string
+	Score             int
+	Replayed          bool
+	Summary           *account.Summary
+	// Ids of the summary history generated by the executer.
+	generated map[string]bool
+}
+
+// Approved - Returns true if the transaction was applied to the account.
+func (d Decision) Approved() bool {
+	return d.Decision == account.Approved || d.Decision == account.ApprovedWithFlags
+}
+
+// ViolationNames - Returns the names of the violations found.
+func (d Decision) ViolationNames() []string {
+	names := []string{}
+	for _, v := range d.Violations {
+		if name := account.Violations[v]; name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// CreateAccount - Creates the account of the account-id, an already,
+// initialized account is reported with the violation and kept as is.
+func (exe *Executer) CreateAccount(ctx context.Context, id AccountID, settings AccountSettings) (Decision, error) {
+	return exe.create(ctx, id, 0, settings)
+}
+
+// UpdateAccount - Changes the settings of the account of the account-id.
+func (exe *Executer) UpdateAccount(ctx context.Context, id AccountID, upd account.Update) (Decision, error) {
+	return exe.update(ctx, id, 0, upd)
+}
+
+// Authorize - Checks the transaction against the account of the account-id,
+// and applies it unless it is declined. A transaction with the id of a,
+// previous one is a retry, it is not checked again and the original,
+// decision is returned as is, even if the other fields differ.
+// Transactions without id are assigned a new one, its checks are traced,
+// as children of the context span.
+func (exe *Executer) Authorize(ctx context.Context, id AccountID, tsn account.Transaction) (Decision, error) {
+	return exe.authorize(ctx, id, 0, tsn)
+}
+
+// create - CreateAccount as the operation number $line, 0 numbers it.
+func (exe *Executer) create(ctx context.Context, id AccountID, line int, settings AccountSettings) (Decision, error) {
+	return exe.run(ctx, id, line, true, message.Account, settings, func(s *slot, line int, d *Decision) {
+		exe.initAccount(s, &settings, d)
+	})
+}
+
+// update - UpdateAccount as the operation number $line, 0 numbers it.
+func (exe *Executer) update(ctx context.Context, id AccountID, line int, upd account.Update) (Decision, error) {
+	return exe.run(ctx, id, line, false, message.AccountUpdate, upd, func(s *slot, line int, d *Decision) {
+		exe.updateAccount(s.account, &upd, d)
+	})
+}
+
+// authorize - Authorize as the operation number $line, 0 numbers it.
+func (exe *Executer) authorize(ctx context.Context, id AccountID, line int, tsn account.Transaction) (Decision, error) {
+	return exe.run(ctx, id, line, false, message.Transaction, tsn, func(s *slot, line int, d *Decision) {
+		if original, ok := s.replays.get(tsn.ID); ok {
+			*d = original
+			d.Replayed = true
+			return
+		}
+
+		key := tsn.ID
+		ids := exe.idGenerator()
+		if tsn.ID == "" {
+			tsn.ID = ids.TransactionID(id, line)
+		}
+		d.TransactionID = tsn.ID
+
+		if m := exe.currentMetrics(); m != nil {
+			m.Amount(tsn.Amount)
+		}
+		exe.processTransaction(ctx, s.account, &tsn, line, d)
+		if d.Approved() {
+			d.AuthorizationCode = ids.AuthorizationCode(id, line)
+		}
+		if s.account != nil {
+			d.Account = state(s.account)
+			// Only the ids given are replayed.
+			s.replays.put(key, *d)
+			if key == "" {
+				s.generate(tsn.ID)
+			}
+		}
+	})
+}
+
+// QueryAccount - Returns the state of the account of the account-id, with,
+// its held amount and the history window of the query, without changing,
+// it. Queries are not numbered for the generated ids, nor recorded in,
+// the audit log, as nothing is decided.
+func (exe *Executer) QueryAccount(ctx context.Context, id AccountID, q account.Query) (Decision, error) {
+	start := time.Now()
+	d := Decision{AccountID: id, Violations: []int{}}
+	if err := ctx.Err(); err != nil {
+		return d, err
+	}
+
+	s := exe.slot(string(id), false)
+	if s == nil {
+		s = &slot{}
+	} else {
+		if err := s.acquire(ctx); err != nil {
+			return d, err
+		}
+		defer s.release()
+	}
+
+	summary, violations := s.account.Query(&q)
+	d.Violations = append(d.Violations, violations...)
+	if s.account != nil {
+		d.Account = &State{
+			Active: summary.Active,
+			Card:   summary.Card,
+			Expiry: summary.Expiry,
+			Locked: summary.Locked,
+			Limit:  summary.Limit,
+		}
+		d.Summary = &summary
+		d.generated = s.generatedIn(summary.History)
+	}
+
+	exe.observe(message.AccountQuery, start, d)
+	exe.logDecision(ctx, message.AccountQuery, d, nil)
+	return d, nil
+}
+
+// run - Runs an operation holding the lock of the account of the account-id,
+// the slot is created if $create, otherwise the operation gets an empty,
+// slot when there is no account. Canceling the context while waiting for,
+// the lock returns the context error, and the operation is not run.
+// Known operations are recorded in the audit log, with their $input, and,
+// logged, every operation run is counted in the metrics. Generated ids are,
+// derived from the operation number $line, 0 numbers it as it is run.
+func (exe *Executer) run(ctx context.Context, id AccountID, line int, create bool, operation string, input interface{}, op func(s *slot, line int, d *Decision)) (Decision, error) {
+	start := time.Now()
+	d := Decision{AccountID: id, Violations: []int{}}
+	if err := ctx.Err(); err != nil {
+		return d, err
+	}
+
+	s := exe.slot(string(id), create)
+	if s == nil {
+		s = &slot{}
+	} else {
+		if err := s.acquire(ctx); err != nil {
+			return d, err
+		}
+		defer s.release()
+	}
+
+	// Operations are numbered in the order they get the lock, unless,
+	// their position in the input is given.
+	if line == 0 {
+		line = int(atomic.AddInt64(&exe.line, 1))
+	}
+	var before *State
+	if s.account != nil {
+		before = state(s.account)
+	}
+	op(s, line, &d)
+	// The operation may have set the state already.
+	if d.Account == nil && s.account != nil {
+		d.Account = state(s.account)
+	}
+
+	exe.observe(operation, start, d)
+	if operation != "" {
+		err := exe.record(operation, input, before, s.account, d)
+		exe.logDecision(ctx, operation, d, err)
+		return d, err
+	}
+	return d, nil
+}
+
+// state - Returns the current state of the account.
+func state(acn *account.Account) *State {
+	return &State{
+		Active: acn.Active(),
+		Card:   acn.Card(),
+		Expiry: acn.Expiry(),
+		Locked: acn.Locked(),
+		Limit:  acn.Limit(),
+	}
+}
@@ -0,0 +1,236 @@
+// ********************************************************************
+// * api_test.go                                                      *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds account query scenarios, JR                      *
+// * 2026-10-19 Adds card state scenario, JR                          *
+// *                                                                  *
+// * This file contains all unit testing related with the typed       *
+// * library API of the executer.                                     *
+// *                                                                  *
+// * Usage: go test -v ./executer                                     *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"authorizer/audit"
+	"authorizer/executer/message"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Test transactions.
+var tapi = map[string]account.Transaction{
+	"Valid": {
+		Merchant: "Burger Queen",
+		Amount:   20,
+		Time:     "2019-02-13T10:00:00.000Z",
+	},
+	"Insufficient": {
+		Merchant: "Habbib's",
+		Amount:   200,
+		Time:     "2019-02-13T10:01:00.000Z",
+	},
+}
+
+// Test account creation, transactions and updates with the typed API.
+func TestAPIOperations(t *testing.T) {
+	ctx := context.Background()
+	exe := Init()
+	assert := assert.New(t)
+
+	d, err := exe.Authorize(ctx, "a1", tapi["Valid"])
+	assert.NoError(err)
+	assert.Nil(d.Account, "Expected no account.")
+	assert.Equal([]int{0}, d.Violations, "Expected account-not-initialized.")
+	assert.Equal(account.Declined, d.Decision)
+	assert.False(d.Approved())
+
+	d, err = exe.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 100})
+	assert.NoError(err)
+	assert.Equal(Decision{
+		AccountID:  "a1",
+		Account:    &State{Active: true, Card: account.CardActive, Limit: 100},
+		Violations: []int{},
+	}, d, "Expected the new account.")
+
+	d, err = exe.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 500})
+	assert.NoError(err)
+	assert.Equal([]string{"account-already-initialized"}, d.ViolationNames())
+	assert.Equal(100, d.Account.Limit, "Expected same account limit.")
+
+	d, err = exe.Authorize(ctx, "a1", tapi["Valid"])
+	assert.NoError(err)
+	assert.True(d.Approved(), "Expected an approved transaction.")
+	assert.Equal(0, d.Score)
+	assert.Equal(80, d.Account.Limit, "Expected the amount taken.")
+
+	d, err = exe.Authorize(ctx, "a1", tapi["Insufficient"])
+	assert.NoError(err)
+	assert.False(d.Approved(), "Expected a declined transaction.")
+	assert.Equal([]string{"insufficient-limit"}, d.ViolationNames())
+	assert.Equal(80, d.Account.Limit, "Expected same account limit.")
+
+	d, err = exe.UpdateAccount(ctx, "a1", account.Update{DeniedMerchants: []string{"Burger Queen"}})
+	assert.NoError(err)
+	assert.Empty(d.Violations)
+	tsn := tapi["Valid"]
+	tsn.Time = "2019-02-13T11:00:00.000Z"
+	d, err = exe.Authorize(ctx, "a1", tsn)
+	assert.NoError(err)
+	assert.Equal([]string{"account-blocked-merchant"}, d.ViolationNames())
+
+	// Other accounts are not changed.
+	d, err = exe.UpdateAccount(ctx, "a2", account.Update{})
+	assert.NoError(err)
+	assert.Equal([]int{0}, d.Violations, "Expected account-not-initialized.")
+}
+
+// Test the account query returns the state and history, without changing,
+// the account, the audit log or the generated ids.
+func TestAPIQueryAccount(t *testing.T) {
+	ctx := context.Background()
+	out := &bytes.Buffer{}
+	exe := Init()
+	exe.SetIDGenerator(SeededIDs(1))
+	exe.SetAudit(audit.New(out))
+	assert := assert.New(t)
+
+	d, err := exe.QueryAccount(ctx, "a1", account.Query{})
+	assert.NoError(err)
+	assert.Equal([]int{0}, d.Violations, "Expected account-not-initialized.")
+	assert.Nil(d.Account)
+	assert.Nil(d.Summary)
+
+	exe.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 100})
+	exe.Authorize(ctx, "a1", tapi["Valid"])
+	exe.Authorize(ctx, "a1", tapi["Insufficient"])
+	audited := out.Len()
+	d, err = exe.QueryAccount(ctx, "a1", account.Query{})
+	assert.NoError(err)
+	assert.Equal(&State{Active: true, Card: account.CardActive, Limit: 80}, d.Account)
+	assert.Equal(80, d.Summary.Limit)
+	assert.Equal(20, d.Summary.Held)
+	if assert.Len(d.Summary.History, 1) {
+		assert.Equal("Burger Queen", d.Summary.History[0].Merchant)
+		assert.NotEmpty(d.Summary.History[0].ID, "Expected the generated id.")
+	}
+	assert.Empty(d.Decision)
+	assert.Equal(audited, out.Len(), "Expected no audit record.")
+
+	// The next transaction gets the same id as without the query.
+	d, _ = exe.Authorize(ctx, "a1", tapi["Valid"])
+	other := Init()
+	other.SetIDGenerator(SeededIDs(1))
+	other.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 100})
+	other.Authorize(ctx, "a1", tapi["Valid"])
+	other.Authorize(ctx, "a1", tapi["Insufficient"])
+	expected, _ := other.Authorize(ctx, "a1", tapi["Valid"])
+	assert.Equal(expected.TransactionID, d.TransactionID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = exe.QueryAccount(ctx, "a1", account.Query{})
+	assert.True(errors.Is(err, context.Canceled), "Expected canceled error.")
+}
+
+// Test accounts are created with the card state and expiry, and not,
+// created with one not valid.
+func TestAPICardSettings(t *testing.T) {
+	ctx := context.Background()
+	exe := Init()
+	assert := assert.New(t)
+
+	d, _ := exe.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 100, Card: "frozen", Expiry: "12/27"})
+	assert.Equal([]string{"invalid-card-transition", "invalid-card-expiry"}, d.ViolationNames())
+	assert.Nil(d.Account, "Expected no account.")
+
+	d, _ = exe.CreateAccount(ctx, "a1", AccountSettings{Active: true, Limit: 100, Card: account.CardBlocked, Expiry: "2027-12"})
+	assert.Empty(d.Violations)
+	assert.Equal(&State{Card: account.CardBlocked, Expiry: "2027-12", Limit: 100}, d.Account)
+
+	d, _ = exe.UpdateAccount(ctx, "a1", account.Update{CardState: account.CardActive})
+	assert.Empty(d.Violations)
+	assert.True(d.Account.Active)
+	d, _ = exe.Authorize(ctx, "a1", tapi["Valid"])
+	assert.True(d.Approved(), "Expected an approved transaction.")
+}
+
+// Test a canceled context returns its error, without running the operation.
+func TestAPICanceled(t *testing.T) {
+	exe := Init()
+	exe.CreateAccount(context.Background(), "", AccountSettings{Active: true, Limit: 100})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert := assert.New(t)
+	_, err := exe.Authorize(ctx, "", tapi["Valid"])
+	assert.True(errors.Is(err, context.Canceled), "Expected canceled error.")
+	_, err = exe.CreateAccount(ctx, "a2", AccountSettings{Active: true, Limit: 100})
+	assert.True(errors.Is(err, context.Canceled), "Expected canceled error.")
+	_, err = exe.ExecContext(ctx, `{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`)
+	assert.True(errors.Is(err, context.Canceled), "Expected canceled error.")
+	assert.Equal(100, snapshotOf(exe)[""].Limit, "Expected same account limit.")
+	assert.NotContains(snapshotOf(exe), "a2", "Expected no account created.")
+}
+
+// Test the context deadline while waiting for the account lock.
+func TestAPIDeadlineWaitingLock(t *testing.T) {
+	exe := Init()
+	exe.CreateAccount(context.Background(), "", AccountSettings{Active: true, Limit: 100})
+	// Another operation holds the account.
+	s := exe.slot("", false)
+	s.acquire(context.Background())
+	defer s.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := exe.Authorize(ctx, "", tapi["Valid"])
+	assert := assert.New(t)
+	assert.True(errors.Is(err, context.DeadlineExceeded), "Expected deadline error.")
+	assert.Equal(100, s.account.Limit(), "Expected same account limit.")
+}
+
+// Test errors of json operation lines, the account output is still returned.
+func TestExecContextErrors(t *testing.T) {
+	ctx := context.Background()
+	exe := Init()
+	assert := assert.New(t)
+
+	out, err := exe.ExecContext(ctx, `{"account": {"active-card": true, "available-limit": 100}}`)
+	assert.NoError(err)
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`, out)
+
+	out, err = exe.ExecContext(ctx, `{"account": `)
+	assert.Error(err, "Expected a malformed line error.")
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`, out)
+
+	out, err = exe.ExecContext(ctx, `{"unknown": {}}`)
+	assert.True(errors.Is(err, ErrUnknownOperation), "Expected unknown operation error.")
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`, out)
+}
+
+// Test account-query json lines, in v1 and v2.
+func TestExecAccountQuery(t *testing.T) {
+	exe := Init()
+	exe.Exec(`{"account": {"active-card": true, "available-limit": 100}}`)
+	exe.Exec(`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`)
+
+	assert := assert.New(t)
+	out := exe.Exec(`{"account-query": {}}`)
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 80}, "account-summary": {"held-amount": 20, "history": [{"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}]}, "violations": []}`, out)
+	assert.NoError(message.ValidateOutput(message.V1, out))
+
+	out = exe.Exec(`{"version": 2, "account-query": {"time": "2019-02-13T11:00:00.000Z"}}`)
+	assert.Equal(`{"version": 2, "operation": "account-query", "account": {"active-card": true, "available-limit": 80}, "account-summary": {"held-amount": 20, "history": []}, "violations": []}`, out)
+	assert.NoError(message.ValidateOutput(message.V2, out))
+
+	out = exe.Exec(`{"account-id": "a2", "account-query": {}}`)
+	assert.Equal(`{"account-id": "a2", "account": {}, "violations": ["account-not-initialized"]}`, out)
+}
@@ -0,0 +1,68 @@
+// ********************************************************************
+// * audit.go                                                         *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Records every operation decision in the audit log, apart from    *
+// * the protocol output, with the account limit before and after     *
+// * it and the rules configuration version.                          *
+// *                                                                  *
+// * Usage:                                                           *
+// * log, err := audit.Open(path, maxSize)                            *
+// * e.SetAudit(log)                                                  *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"authorizer/audit"
+	"encoding/json"
+	"fmt"
+)
+
+// SetAudit - Enables the audit log of the operations, nil disables it.
+func (exe *Executer) SetAudit(log *audit.Log) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.audit = log
+}
+
+// record - Writes the operation audit record, the input hash is the hash,
+// of the account-id and the json of the operation input.
+func (exe *Executer) record(operation string, input interface{}, before *State, acn *account.Account, d Decision) error {
+	exe.mu.RLock()
+	log := exe.audit
+	exe.mu.RUnlock()
+	if log == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAudit, err)
+	}
+	rec := &audit.Record{
+		InputHash:     audit.Hash(append([]byte(string(d.AccountID)+"\n"), b...)),
+		AccountID:     string(d.AccountID),
+		Operation:     operation,
+		TransactionID: d.TransactionID,
+		Replayed:      d.Replayed,
+		Decision:      d.Decision,
+		Violations:    d.ViolationNames(),
+		RulesVersion:  acn.Rules().Version(),
+	}
+	if before != nil {
+		rec.LimitBefore = &before.Limit
+	}
+	// A replay leaves the account as is, not as in its decision.
+	if acn != nil {
+		limit := acn.Limit()
+		rec.LimitAfter = &limit
+	}
+
+	if err := log.Write(rec); err != nil {
+		return fmt.Errorf("%w: %v", ErrAudit, err)
+	}
+	return nil
+}
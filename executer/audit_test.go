@@ -0,0 +1,127 @@
+// ********************************************************************
+// * audit_test.go                                                    *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the audit       *
+// * records written by the executer.                                 *
+// *                                                                  *
+// * Usage: go test -v -run Audit ./executer                          *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"authorizer/audit"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// Test input audited.
+var taudit = []string{
+	`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	`{"account": {"active-card": true, "available-limit": 100}}`,
+	`{"unknown": {}}`,
+	`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	`{"account-update": {"denied-merchants": ["Habbib's"]}}`,
+}
+
+// records - Returns the audit records written.
+func records(t *testing.T, out *bytes.Buffer) []*audit.Record {
+	recs := []*audit.Record{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		rec := &audit.Record{}
+		if err := json.Unmarshal([]byte(line), rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs
+}
+
+// Test every known operation is recorded, with the limit before and after.
+func TestAuditRecords(t *testing.T) {
+	out := &bytes.Buffer{}
+	exe := Init()
+	exe.SetIDGenerator(SeededIDs(1))
+	exe.SetAudit(audit.New(out))
+	for _, op := range taudit {
+		exe.Exec(op)
+	}
+
+	recs := records(t, out)
+	assert := assert.New(t)
+	assert.Len(recs, 5, "Expected unknown operations not recorded.")
+
+	version := account.DefaultRules().Version()
+	limit := func(l int) *int { return &l }
+	expected := []audit.Record{
+		{Operation: "transaction", Decision: "declined", Violations: []string{"account-not-initialized"}, RulesVersion: version},
+		{Operation: "account", Violations: []string{}, LimitAfter: limit(100), RulesVersion: version},
+		{Operation: "transaction", TransactionID: "t1", Decision: "approved", Violations: []string{}, LimitBefore: limit(100), LimitAfter: limit(80), RulesVersion: version},
+		{Operation: "transaction", TransactionID: "t1", Replayed: true, Decision: "approved", Violations: []string{}, LimitBefore: limit(80), LimitAfter: limit(80), RulesVersion: version},
+		{Operation: "account-update", Violations: []string{}, LimitBefore: limit(80), LimitAfter: limit(80), RulesVersion: version},
+	}
+	for i, rec := range recs {
+		assert.Equal(i+1, rec.Seq)
+		assert.Len(rec.InputHash, 64, "Expected a sha256 input hash.")
+		assert.Equal(expected[i].Operation, rec.Operation)
+		assert.Equal(expected[i].Replayed, rec.Replayed)
+		assert.Equal(expected[i].Decision, rec.Decision)
+		assert.Equal(expected[i].Violations, rec.Violations)
+		assert.Equal(expected[i].LimitBefore, rec.LimitBefore)
+		assert.Equal(expected[i].LimitAfter, rec.LimitAfter)
+		assert.Equal(expected[i].RulesVersion, rec.RulesVersion)
+		if expected[i].TransactionID != "" {
+			assert.Equal(expected[i].TransactionID, rec.TransactionID)
+		}
+	}
+	assert.NotEmpty(recs[0].TransactionID, "Expected an assigned transaction id.")
+	assert.Equal(recs[2].InputHash, recs[3].InputHash, "Expected same input hash for the retry.")
+	assert.NotEqual(recs[0].InputHash, recs[2].InputHash, "Expected another input hash.")
+
+	_, err := audit.Verify(strings.NewReader(out.String()), "", 0)
+	assert.NoError(err, "Expected a valid chain.")
+}
+
+// Test the rules version changes with the account rules.
+func TestAuditRulesVersion(t *testing.T) {
+	out := &bytes.Buffer{}
+	rules := account.DefaultRules()
+	rules.MaxAmount = 60
+	exe := Init()
+	exe.SetRules(rules)
+	exe.SetAudit(audit.New(out))
+	exe.Exec(taudit[1])
+	exe.Exec(taudit[3])
+
+	for _, rec := range records(t, out) {
+		assert.Equal(t, rules.Version(), rec.RulesVersion, "Expected the account rules version.")
+	}
+}
+
+// failing - A writer always failing.
+type failing struct{}
+
+func (failing) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// Test operations not audited are still run and output, with the error.
+func TestAuditError(t *testing.T) {
+	exe := Init()
+	exe.SetAudit(audit.New(failing{}))
+	out, err := exe.ExecContext(context.Background(), taudit[1])
+	assert := assert.New(t)
+	assert.True(errors.Is(err, ErrAudit), "Expected an audit error.")
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`, out)
+	assert.Equal(100, snapshotOf(exe)[""].Limit, "Expected the account created.")
+}
@@ -2,6 +2,29 @@
 // * executer.go                                                      *
 // *                                                                  *
 // * 2020-03-16 First Version, JR                                     *
+// * 2026-10-19 Adds account-update operation, JR                     *
+// * 2026-10-19 Adds configurable account rules, JR                   *
+// * 2026-10-19 Adds optional decision output, JR                     *
+// * 2026-10-19 Adds shadow evaluation of candidate rules, JR         *
+// * 2026-10-19 Ignores unknown and malformed operations, JR          *
+// * 2026-10-19 Adds multiple accounts by account-id, JR              *
+// * 2026-10-19 Makes Executer safe for concurrent use, JR            *
+// * 2026-10-19 Makes Exec an adapter over the typed API, JR          *
+// * 2026-10-19 Adds idempotency cache by account, JR                 *
+// * 2026-10-19 Adds optional transaction id output, JR               *
+// * 2026-10-19 Adds audit log, JR                                    *
+// * 2026-10-19 Adds prometheus metrics, JR                           *
+// * 2026-10-19 Adds tracing spans, JR                                *
+// * 2026-10-19 Adds structured logs, JR                              *
+// * 2026-10-19 Adds protocol versions and validation, JR             *
+// * 2026-10-19 Adds account-query operation, JR                      *
+// * 2026-10-19 Adds card lock output, JR                             *
+// * 2026-10-19 Adds card state and expiry, JR                        *
+// * 2026-10-19 Fixes SetRules lock order with running operations, JR *
+// * 2026-10-19 Counts accounts by their card state, JR               *
+// * 2026-10-19 Outputs generated ids in queries only if enabled, JR  *
+// * 2026-10-19 Marks outputs with their operation, JR                *
+// * 2026-10-19 Numbers streamed operations by input position, JR     *
 // *                                                                  *
 // * Package responsible of build an output json line                 *
 // * based in another input json message.                             *
@@ -9,20 +32,80 @@
 // * Usage:                                                           *
 // * e:= executer.Init()                                              *
 // * e.Exec(string)                                                   *
+// * out, err := e.ExecContext(ctx, string)                           *
 // ********************************************************************
 
 package executer
 
 import (
 	"authorizer/account"
+	"authorizer/audit"
 	"authorizer/executer/message"
-	"encoding/json"
-	"strings"
+	"authorizer/metrics"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"sync"
+	"time"
 )
 
-// Executer - Holds the reference to the working account.
+// Executer - Holds the reference to the working accounts by account-id,
+// operations without account-id work with the "" account, the rules,
+// the accounts are created with, if decisions and transaction ids are,
+// output, the candidate rules evaluated in shadow, the number of,
+// transaction ids kept by account, the transaction ids and authorization,
+// codes generator, the audit log, the metrics, the spans tracer, the logger,
+// the outputs protocol version, if operations are validated, and the input,
+// line number.
+// It is safe for concurrent use, the lock only guards the accounts map,
+// and the settings, while each account has its own lock to run one,
+// operation at a time, so operations over different accounts run,
+// concurrently, and each output has the account right after its operation.
+// An account lock is never waited for while holding the executer lock.
 type Executer struct {
-	account *account.Account
+	mu             sync.RWMutex
+	accounts       map[string]*slot
+	rules          *account.Rules
+	decisions      bool
+	transactionIDs bool
+	shadow         *shadow
+	idempotency    int
+	ids            IDGenerator
+	audit          *audit.Log
+	metrics        *metrics.Metrics
+	tracer         trace.Tracer
+	logger         *slog.Logger
+	outputVersion  int
+	validation     bool
+	line           int64
+}
+
+// slot - Holds an account and its transactions decisions by id, the lock,
+// serializes the operations over it, it is a channel so waiting for it,
+// can be canceled.
+type slot struct {
+	lock      chan struct{}
+	account   *account.Account
+	replays   *replays
+	generated map[string]bool
+}
+
+// acquire - Waits for the slot lock, or returns the context error,
+// if it is canceled first.
+func (s *slot) acquire(ctx context.Context) error {
+	select {
+	case s.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release - Releases the slot lock.
+func (s *slot) release() {
+	<-s.lock
 }
 
 // Init Returns a new Executer.
@@ -30,67 +113,283 @@ func Init() *Executer {
 	return &Executer{}
 }
 
+// SetRules - Changes the rules used to check transactions, nil restores,
+// the default ones.
+func (exe *Executer) SetRules(r *account.Rules) {
+	exe.mu.Lock()
+	exe.rules = r
+	exe.mu.Unlock()
+	for _, s := range exe.slots() {
+		s.acquire(context.Background())
+		if s.account != nil {
+			// The rules set last, if another call changed them meanwhile.
+			exe.mu.RLock()
+			s.account.SetRules(exe.rules)
+			exe.mu.RUnlock()
+		}
+		s.release()
+	}
+}
+
+// slots - Returns the slots of the accounts, to be acquired without the,
+// executer lock, as operations holding a slot take it.
+func (exe *Executer) slots() []*slot {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	slots := make([]*slot, 0, len(exe.accounts))
+	for _, s := range exe.accounts {
+		slots = append(slots, s)
+	}
+
+	return slots
+}
+
+// SetDecisions - Enables or disables the decision and risk score output,
+// for transactions.
+func (exe *Executer) SetDecisions(enabled bool) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.decisions = enabled
+}
+
+// slot - Returns the slot of the account-id, nil if there is no one,
+// and it is not created.
+func (exe *Executer) slot(id string, create bool) *slot {
+	exe.mu.RLock()
+	s := exe.accounts[id]
+	exe.mu.RUnlock()
+	if s != nil || !create {
+		return s
+	}
+
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	// Another operation could create it while the lock was released.
+	if s = exe.accounts[id]; s == nil {
+		if exe.accounts == nil {
+			exe.accounts = map[string]*slot{}
+		}
+		s = &slot{lock: make(chan struct{}, 1)}
+		exe.accounts[id] = s
+	}
+
+	return s
+}
+
 // Exec - Returns a json line string build based in a json operation line.
+// Unknown, malformed or not valid operations are ignored and only the,
+// account is returned.
 func (exe *Executer) Exec(op string) string {
+	output, _ := exe.ExecContext(context.Background(), op)
+	return output
+}
+
+// ExecContext - Returns the json output line of a json operation line,
+// run with the typed API. Unknown or malformed operations return the,
+// account output with the error, canceled operations only the error.
+// Operations not recorded in the audit log return the output with the error.
+// The operation runs in an "exec" span, with its decoding and encoding.
+func (exe *Executer) ExecContext(ctx context.Context, op string) (string, error) {
+	start := time.Now()
 	// Transform json string to Message struct.
-	msg := message.New(nil, nil, []string{})
-	json.Unmarshal([]byte(op), msg)
+	msg, err := message.Decode(op)
+	output := ""
+	err = exe.exec(ctx, start, 0, msg, op, err, func(out *message.Message) error {
+		output = message.Encode(out)
+		return nil
+	})
+
+	return output, err
+}
+
+// exec - Runs the operation message decoded since $start, with the decode,
+// error if any, and writes its output message with $write. Operations not,
+// valid against their schema are ignored, as the unknown ones, and logged,
+// with their raw $input. Returns the error of the operation, or the $write,
+// one, canceled operations are not written. $line is the position of the,
+// operation in the input, from 1, or 0 to number it as it is run.
+func (exe *Executer) exec(ctx context.Context, start time.Time, line int, msg *message.Message, input string, err error, write func(*message.Message) error) error {
+	tracer := exe.currentTracer()
+	ctx, span := tracer.Start(ctx, "exec", trace.WithTimestamp(start))
+	defer span.End()
 
-	// Check operation type is "account".
-	if msg.Type() == message.Account {
-		exe.initAccount(msg)
+	_, decode := tracer.Start(ctx, "decode", trace.WithTimestamp(start))
+	if err == nil {
+		err = exe.validate(msg)
 	}
+	fail(decode, err)
+	operation := msg.Type()
+	if err == nil && operation == "" {
+		err = ErrUnknownOperation
+	}
+	if err != nil {
+		// Not valid operations keep the account-id to output its account.
+		operation = ""
+		exe.logIgnored(ctx, input, err)
+	}
+	decode.SetAttributes(attribute.String("operation", operation))
+	decode.End()
+
+	id := AccountID(msg.AccountID)
+	var d Decision
+	var opErr error
+	switch operation {
+	case message.Account:
+		d, opErr = exe.create(ctx, id, line, AccountSettings{
+			Active:           msg.Account.Active,
+			Limit:            msg.Account.Limit,
+			AllowedMerchants: msg.Account.AllowedMerchants,
+			DeniedMerchants:  msg.Account.DeniedMerchants,
+			Card:             msg.Account.CardState,
+			Expiry:           msg.Account.CardExpiry,
+		})
+	case message.AccountUpdate:
+		d, opErr = exe.update(ctx, id, line, *msg.Update)
+	case message.AccountQuery:
+		d, opErr = exe.QueryAccount(ctx, id, *msg.Query)
+	case message.Transaction:
+		d, opErr = exe.authorize(ctx, id, line, *msg.Transaction)
+	default:
+		// Only the account is returned.
+		d, opErr = exe.run(ctx, id, line, false, "", nil, func(*slot, int, *Decision) {})
+	}
+	span.SetAttributes(attributes(operation, d)...)
+	// Operations not audited are still output.
+	if opErr != nil && !errors.Is(opErr, ErrAudit) {
+		fail(span, opErr)
+		return opErr
+	}
+	ignored := err
+	if opErr != nil {
+		err = opErr
+	}
+	fail(span, err)
 
-	// If is a "transaction" message then:
-	if msg.Type() == message.Transaction {
-		exe.processTransaction(msg)
+	_, encode := tracer.Start(ctx, "encode", trace.WithAttributes(attributes(operation, d)...))
+	defer encode.End()
+	if werr := write(exe.encode(operation, d, exe.version(msg), ignored)); werr != nil {
+		fail(encode, werr)
+		return werr
 	}
 
-	// Clean temporary data from our output structure message,
-	// in order to be converted to json.
-	msg.Transaction = nil
-	//msg.Account = &message.AccountMessage{}
-	// TODO: Check with nubank how we going to handle json message for "account",
-	// When account is not initialized.
-	// Currently assuming `{"account": {}, "violations":[]}`
-	if exe.account != nil {
+	return err
+}
+
+// encode - Returns the output message of the operation decision, in the,
+// protocol version, with the error the operation was $ignored by, if any.
+func (exe *Executer) encode(operation string, d Decision, version int, ignored error) *message.Message {
+	msg := message.New(nil, nil, []string{})
+	msg.SetOutput(operation)
+	msg.AccountID = string(d.AccountID)
+	// Accounts not initialized are `{"account": {}, "violations": []}` in v1,
+	// and have no account in v2.
+	if d.Account != nil {
 		msg.Account = &message.AccountMessage{
-			Active: exe.account.Active(),
-			Limit:  exe.account.Limit(),
+			Active: d.Account.Active,
+			Limit:  d.Account.Limit,
+			Locked: d.Account.Locked,
+			// Active and inactive cards are told by the active flag.
+			CardExpiry: d.Account.Expiry,
+		}
+		if d.Account.Card != account.CardActive && d.Account.Card != account.CardInactive {
+			msg.Account.CardState = d.Account.Card
+		}
+	}
+	if d.Summary != nil {
+		msg.Summary = &message.SummaryMessage{
+			Held:    d.Summary.Held,
+			History: d.Summary.History,
 		}
 	}
+	for _, v := range d.Violations {
+		msg.AddViolation(v)
+	}
+	if version == message.V2 {
+		encodeV2(msg, operation, d, ignored)
+		return msg
+	}
 
-	// Converting to json.
-	output, _ := json.Marshal(msg)
-	// Prettify with no null refs and spaces.
-	result := strings.Replace(string(output), "null", "{}", -1)
-	result = strings.Replace(result, ":", ": ", -1)
-	result = strings.Replace(result, ",", ", ", -1)
-	return result
+	exe.mu.RLock()
+	decisions := exe.decisions
+	transactionIDs := exe.transactionIDs
+	exe.mu.RUnlock()
+	if transactionIDs && operation == message.Transaction {
+		msg.TransactionID = d.TransactionID
+		msg.AuthorizationCode = d.AuthorizationCode
+	}
+	if !transactionIDs && msg.Summary != nil {
+		msg.Summary.History = d.givenHistory()
+	}
+	if decisions && operation == message.Transaction {
+		msg.SetDecision(d.Decision, d.Score)
+	}
+
+	return msg
 }
 
 // initAccount - Create a new account and add the reference to the executioner,
 // additionaly adds violations if there was found.
-func (exe *Executer) initAccount(msg *message.Message) {
+func (exe *Executer) initAccount(s *slot, settings *AccountSettings, d *Decision) {
 	// Try init account.
-	acn, v := exe.account.Init(msg.Account.Active, msg.Account.Limit)
+	acn, v := s.account.Init(settings.Active, settings.Limit)
 	// If violation found.
 	if v != -1 {
-		// Then add to message.
-		msg.AddViolation(v)
+		// Then add to the decision.
+		d.Violations = append(d.Violations, v)
+	} else if v := account.ValidateCard(settings.Card, settings.Expiry); len(v) > 0 {
+		// The account is not created with a card not valid.
+		d.Violations = append(d.Violations, v...)
 	} else {
-		exe.account = acn
+		s.account = acn
+		s.replays = exe.newReplays()
+		exe.mu.RLock()
+		acn.SetRules(exe.rules)
+		acn.SetLogger(exe.logger)
+		exe.mu.RUnlock()
+		acn.InitCard(settings.Card, settings.Expiry)
+		// Merchant lists are optional on creation.
+		acn.ApplyUpdate(&account.Update{
+			AllowedMerchants: settings.AllowedMerchants,
+			DeniedMerchants:  settings.DeniedMerchants,
+		})
+		// Counted once the card state is set, usable only if active.
+		if m := exe.currentMetrics(); m != nil {
+			m.AccountCreated(acn.Active())
+		}
+	}
+}
+
+// updateAccount - Changes the operation account's settings and add violations,
+// if there was found in the process.
+func (exe *Executer) updateAccount(acn *account.Account, upd *account.Update, d *Decision) {
+	active := acn != nil && acn.Active()
+	d.Violations = append(d.Violations, acn.ApplyUpdate(upd)...)
+	// The account is moved when its card changes from usable or to it.
+	if m := exe.currentMetrics(); m != nil && acn != nil && acn.Active() != active {
+		m.AccountChanged(!active)
 	}
 }
 
 // processTransaction - Execute a transaction and add violations if there was,
-// found in the process.
-func (exe *Executer) processTransaction(msg *message.Message) {
+// found in the process, with the decision and risk score.
+func (exe *Executer) processTransaction(ctx context.Context, acn *account.Account, tsn *account.Transaction, line int, d *Decision) {
+	exe.mu.RLock()
+	shadow := exe.shadow
+	exe.mu.RUnlock()
+
+	// The candidate rules are checked before the account changes.
+	var candidate *Outcome
+	if shadow != nil {
+		candidate = shadow.evaluate(acn, tsn)
+	}
+
 	// check if account is initialized.
-	violations := exe.account.ApplyTransaction(msg.Transaction)
-	if len(violations) > 0 {
-		for _, v := range violations {
-			msg.AddViolation(v)
-		}
+	violations := acn.ApplyTransactionContext(ctx, tsn)
+	d.Violations = append(d.Violations, violations...)
+	d.Decision, d.Score = acn.Rules().Decide(violations)
+
+	if shadow != nil {
+		active := outcome(acn.Rules(), violations)
+		shadow.compare(line, tsn, active, candidate)
 	}
 }
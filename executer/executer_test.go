@@ -4,19 +4,43 @@
 // * 2020-03-15 First Version, JR                                     *
 // * 2020-03-18 Adds multiple violation scnario, JR                   *
 // * 2020-11-18 Simplifies transaction tests in a single function, JR *
+// * 2026-10-19 Adds unknown operations scenario and fuzz test, JR    *
+// * 2026-10-19 Adds Exec benchmarks, JR                              *
+// * 2026-10-19 Adds concurrent Exec stress test, JR                  *
+// * 2026-10-19 Adds card lock by too many declines scenario, JR      *
+// * 2026-10-19 Adds concurrent setters stress test, JR               *
+// * 2026-10-19 Adds isolated accounts by account-id scenario, JR     *
+// * 2026-10-19 Checks the limit is never negative when fuzzing, JR   *
+// * 2026-10-19 Adds negative limit scenario, JR                      *
 // *                                                                  *
 // * This file contains all unit testing related with executer.       *                                                    *
 // *                                                                  *
 // * Usage: go test -v ./executer                                     *
+// * Fuzzing: go test -fuzz FuzzExec ./executer                       *
+// * Race: go test -race -run Concurrent ./executer                   *
+// * Benchmarks: go test -run XXX -bench . ./executer                 *
 // ********************************************************************
 
 package executer
 
 import (
 	"authorizer/account"
+	"authorizer/generator"
+	"authorizer/metrics"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test cases scenarios to cover our executer unit testing.
@@ -64,6 +88,115 @@ var tinputs = map[string]map[string][]string{
 		},
 	},
 
+	"AccountMerchantLists": {
+		"in": []string{
+			`{"account": {"active-card": true, "available-limit": 100, "denied-merchants": ["Burger Queen"]}}`,
+			`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"transaction": {"merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"account-update": {"allowed-merchants": ["Habbib's"], "denied-merchants": []}}`,
+			`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		},
+		"out": []string{
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[7]),
+			fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[6]),
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[8]),
+			`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+		},
+	},
+
+	"UpdateNotInitialized": {
+		"in": []string{
+			`{"account-update": {"denied-merchants": ["Burger Queen"]}}`,
+		},
+		"out": []string{
+			fmt.Sprintf(`{"account": {}, "violations": ["%v"]}`, account.Violations[0]),
+		},
+	},
+
+	"MultipleAccounts": {
+		"in": []string{
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+			`{"account-id": "a2", "account": {"active-card": true, "available-limit": 50}}`,
+			`{"account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"account-id": "a2", "transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"account-id": "a3", "transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		},
+		"out": []string{
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			`{"account-id": "a2", "account": {"active-card": true, "available-limit": 50}, "violations": []}`,
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+			`{"account-id": "a2", "account": {"active-card": true, "available-limit": 30}, "violations": []}`,
+			fmt.Sprintf(`{"account-id": "a3", "account": {}, "violations": ["%v"]}`, account.Violations[0]),
+			fmt.Sprintf(`{"account": {}, "violations": ["%v"]}`, account.Violations[0]),
+		},
+	},
+
+	"AccountsIsolated": {
+		"in": []string{
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+			`{"account-id": "a2", "account": {"active-card": false, "available-limit": 100}}`,
+			`{"account": {"active-card": true, "available-limit": 10}}`,
+			`{"account-id": "a1", "account-update": {"denied-merchants": ["Habbib's"]}}`,
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+			`{"account-id": "a1", "transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"account-id": "a2", "transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"transaction": {"merchant": "Habbib's", "amount": 10, "time": "2019-02-13T10:00:00.000Z"}}`,
+		},
+		"out": []string{
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			`{"account-id": "a2", "account": {"active-card": false, "available-limit": 100}, "violations": []}`,
+			`{"account": {"active-card": true, "available-limit": 10}, "violations": []}`,
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			fmt.Sprintf(`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[2]),
+			fmt.Sprintf(`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[7]),
+			fmt.Sprintf(`{"account-id": "a2", "account": {"active-card": false, "available-limit": 100}, "violations": ["%v"]}`, account.Violations[1]),
+			fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 10}, "violations": ["%v"]}`, account.Violations[3]),
+			`{"account": {"active-card": true, "available-limit": 0}, "violations": []}`,
+		},
+	},
+
+	"NegativeLimit": {
+		"in": []string{
+			`{"account": {"active-card": true, "available-limit": -100}}`,
+			`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"account": {"active-card": true, "available-limit": 0}}`,
+		},
+		"out": []string{
+			fmt.Sprintf(`{"account": {}, "violations": ["%v"]}`, account.Violations[11]),
+			fmt.Sprintf(`{"account": {}, "violations": ["%v"]}`, account.Violations[0]),
+			`{"account": {"active-card": true, "available-limit": 0}, "violations": []}`,
+		},
+	},
+
+	"AccountIDKeptAsIs": {
+		"in": []string{
+			`{"account-id": "null, \"a\":1", "account": {"active-card": true, "available-limit": 100}}`,
+		},
+		"out": []string{
+			`{"account-id": "null, \"a\":1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		},
+	},
+
+	"UnknownOperations": {
+		"in": []string{
+			`{"account": {"active-card": true, "available-limit": 100}}`,
+			"\n",
+			`{"transaction": {"merchant": "Burger Queen", "amount": "20"}}`,
+			`{"unknown": {}, "violations": ["doubled-transaction"]}`,
+		},
+		"out": []string{
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+			`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		},
+	},
+
 	"insufficientLimit": {
 		"in": []string{
 			`{"account": {"active-card": true, "available-limit": 100}}`,
@@ -151,3 +284,300 @@ func TestTransactions(t *testing.T) {
 		})
 	}
 }
+
+// Test transactions with rules set to the executer.
+func TestTransactionsWithRules(t *testing.T) {
+	rules := account.DefaultRules()
+	rules.MaxAmount = 60
+	exe := Init()
+	exe.SetRules(rules)
+
+	in := []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger Queen", "amount": 50, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:03:00.000Z"}}`,
+	}
+	out := []string{
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		`{"account": {"active-card": true, "available-limit": 50}, "violations": []}`,
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 50}, "violations": ["%v"]}`, account.Violations[9]),
+		`{"account": {"active-card": true, "available-limit": 30}, "violations": []}`,
+	}
+
+	assert := assert.New(t)
+	for index, value := range out {
+		assert.Equal(
+			value,
+			exe.Exec(in[index]),
+			"Expected same output from execution.",
+		)
+	}
+}
+
+// Test the card locked by too many declines is output, and unlocked by,
+// an account update.
+func TestTransactionsWithDeclinesLock(t *testing.T) {
+	rules := account.DefaultRules()
+	rules.MaxDeclines = 2
+	exe := Init()
+	exe.SetRules(rules)
+
+	in := []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger Queen", "amount": 500, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:02:00.000Z"}}`,
+		`{"account-update": {"card-locked": false}}`,
+		`{"transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:03:00.000Z"}}`,
+	}
+	out := []string{
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": ["insufficient-limit"]}`,
+		`{"account": {"active-card": true, "available-limit": 100, "card-locked": true}, "violations": ["blocked-merchant"]}`,
+		`{"account": {"active-card": true, "available-limit": 100, "card-locked": true}, "violations": ["too-many-declines"]}`,
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+	}
+
+	assert := assert.New(t)
+	for index, value := range out {
+		assert.Equal(
+			value,
+			exe.Exec(in[index]),
+			"Expected same output from execution.",
+		)
+	}
+}
+
+// Test transactions with decisions output.
+func TestTransactionsWithDecisions(t *testing.T) {
+	rules := account.DefaultRules()
+	rules.Actions[5] = account.Flag
+	exe := Init()
+	exe.SetRules(rules)
+	exe.SetDecisions(true)
+
+	in := []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger Queen1", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"merchant": "Burger Queen2", "amount": 30, "time": "2019-02-13T10:00:30.000Z"}}`,
+		`{"transaction": {"merchant": "Burger Queen3", "amount": 10, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"transaction": {"merchant": "Burger Queen", "amount": 10, "time": "2019-02-13T10:02:00.000Z"}}`,
+		`{"transaction": {"merchant": "Burger King", "amount": 10, "time": "2019-02-13T11:00:00.000Z"}}`,
+	}
+	out := []string{
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		`{"account": {"active-card": true, "available-limit": 80}, "violations": [], "decision": "approved", "risk-score": 0}`,
+		`{"account": {"active-card": true, "available-limit": 50}, "violations": [], "decision": "approved", "risk-score": 0}`,
+		`{"account": {"active-card": true, "available-limit": 40}, "violations": [], "decision": "approved", "risk-score": 0}`,
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 30}, "violations": ["%v"], "decision": "approved-with-flags", "risk-score": 30}`, account.Violations[5]),
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 30}, "violations": ["%v"], "decision": "declined", "risk-score": 100}`, account.Violations[6]),
+	}
+
+	assert := assert.New(t)
+	for index, value := range out {
+		assert.Equal(
+			value,
+			exe.Exec(in[index]),
+			"Expected same output from execution.",
+		)
+	}
+}
+
+// snapshot - account state compared by the fuzz test.
+type snapshot struct {
+	Limit   int
+	Active  bool
+	Allowed []string
+	Denied  []string
+}
+
+// snapshotOf - Returns the state of the executer's accounts.
+func snapshotOf(exe *Executer) map[string]snapshot {
+	snapshots := map[string]snapshot{}
+	for id, s := range exe.accounts {
+		if s.account == nil {
+			continue
+		}
+		snapshots[id] = snapshot{
+			Limit:   s.account.Limit(),
+			Active:  s.account.Active(),
+			Allowed: s.account.AllowedMerchants(),
+			Denied:  s.account.DeniedMerchants(),
+		}
+	}
+
+	return snapshots
+}
+
+// Fuzz execution of json operation lines, seeded with e2e inputs.
+func FuzzExec(f *testing.F) {
+	ins, _ := filepath.Glob(filepath.Join("..", "test", "*", "in"))
+	for _, in := range ins {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+
+	f.Fuzz(func(t *testing.T, ops string) {
+		exe := Init()
+		for _, op := range strings.Split(ops, "\n") {
+			before := snapshotOf(exe)
+			out := exe.Exec(op)
+
+			// Output is always a valid json line.
+			result := &struct {
+				Violations []string `json:"violations"`
+			}{}
+			if err := json.Unmarshal([]byte(out), result); err != nil {
+				t.Fatalf("Expected valid json output for %q, got %q.", op, out)
+			}
+
+			after := snapshotOf(exe)
+			// The available limit never goes negative.
+			for _, acn := range after {
+				if acn.Limit < 0 {
+					t.Fatalf("Expected limit not negative after %q, got %q.", op, out)
+				}
+			}
+
+			// A rejected line never changes the account.
+			if len(result.Violations) > 0 && !reflect.DeepEqual(before, after) {
+				t.Fatalf("Expected account unchanged after %q, got %q.", op, out)
+			}
+		}
+	})
+}
+
+// Test concurrent operations over the same and different accounts,
+// no account spends more than its limit, and the approved transactions,
+// are the ones taken from the limit.
+func TestConcurrentExec(t *testing.T) {
+	const accounts = 4
+	const limit = 100
+	const amount = 10
+	// Twice the transactions each limit allows.
+	const transactions = 2 * limit / amount
+	exe := Init()
+
+	// Accounts are created concurrently too, only one creation succeeds.
+	var wg sync.WaitGroup
+	for i := 0; i < accounts; i++ {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				exe.Exec(fmt.Sprintf(`{"account-id": %q, "account": {"active-card": true, "available-limit": %d}}`, id, limit))
+			}(fmt.Sprintf("account-%d", i))
+		}
+	}
+	wg.Wait()
+
+	spent := make([]int, accounts)
+	var mu sync.Mutex
+	for i := 0; i < accounts; i++ {
+		for j := 0; j < transactions; j++ {
+			wg.Add(1)
+			go func(i int, j int) {
+				defer wg.Done()
+				// One hour apart and by different merchants, so only,
+				// the limit declines.
+				out := exe.Exec(fmt.Sprintf(
+					`{"account-id": "account-%d", "transaction": {"merchant": "Fulanito%d", "amount": %d, "time": "2019-02-13T%02d:00:00.000Z"}}`,
+					i, j, amount, j,
+				))
+				if strings.HasSuffix(out, `"violations": []}`) {
+					mu.Lock()
+					spent[i] += amount
+					mu.Unlock()
+				}
+			}(i, j)
+		}
+	}
+	wg.Wait()
+
+	assert := assert.New(t)
+	for id, snap := range snapshotOf(exe) {
+		assert.Equal(0, snap.Limit, "Expected no limit left in %s.", id)
+	}
+	for i := range spent {
+		assert.Equal(limit, spent[i], "Expected the whole limit spent in account-%d.", i)
+	}
+}
+
+// Test settings changed while operations run, over the same accounts,
+// never block them.
+func TestConcurrentSetters(t *testing.T) {
+	const accounts = 4
+	const operations = 200
+	ctx := context.Background()
+	exe := Init()
+	exe.SetMetrics(metrics.New())
+	for i := 0; i < accounts; i++ {
+		exe.CreateAccount(ctx, AccountID(fmt.Sprint(i)), AccountSettings{Active: true, Limit: 1000000})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < accounts; i++ {
+			wg.Add(2)
+			go func(id AccountID) {
+				defer wg.Done()
+				for j := 0; j < operations; j++ {
+					exe.Authorize(ctx, id, account.Transaction{
+						Merchant: fmt.Sprint("Fulanito", j),
+						Amount:   1,
+						Time:     fmt.Sprintf("2019-02-13T%02d:%02d:00.000Z", j/60, j%60),
+					})
+				}
+			}(AccountID(fmt.Sprint(i)))
+			go func() {
+				defer wg.Done()
+				for j := 0; j < operations; j++ {
+					exe.SetRules(account.DefaultRules())
+					exe.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+					exe.SetDecisions(j%2 == 0)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Expected operations and setters not blocked.")
+	}
+}
+
+// Benchmark execution of generated streams, single and multiple accounts,
+// each iteration executes a whole stream.
+func BenchmarkExec(b *testing.B) {
+	for _, accounts := range []int{1, 100} {
+		cfg := generator.DefaultConfig()
+		cfg.Accounts = accounts
+		cfg.Transactions = 1000
+		cfg.Limit = 1000000
+		stream := &bytes.Buffer{}
+		if err := generator.Generate(stream, cfg); err != nil {
+			b.Fatal(err)
+		}
+		ops := strings.Split(strings.TrimSpace(stream.String()), "\n")
+
+		b.Run(fmt.Sprintf("accounts-%d", accounts), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				exe := Init()
+				for _, op := range ops {
+					exe.Exec(op)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(ops))/b.Elapsed().Seconds(), "ops/s")
+		})
+	}
+}
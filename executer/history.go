@@ -0,0 +1,92 @@
+// ********************************************************************
+// * history.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Drops the generated ids unless enabled, JR            *
+// *                                                                  *
+// * Read access to the accounts histories of the executer, to list   *
+// * or export the transactions authorized by account-id.             *
+// *                                                                  *
+// * Usage:                                                           *
+// * for _, id := range e.Accounts() {                                *
+// *     for entry := range e.History(id, account.Filter{}) {         *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"context"
+	"iter"
+	"sort"
+)
+
+// Accounts - Returns the account-ids of the initialized accounts, sorted.
+func (exe *Executer) Accounts() []AccountID {
+	exe.mu.RLock()
+	slots := make(map[string]*slot, len(exe.accounts))
+	for id, s := range exe.accounts {
+		slots[id] = s
+	}
+	exe.mu.RUnlock()
+
+	ids := []AccountID{}
+	for id, s := range slots {
+		if s.get() != nil {
+			ids = append(ids, AccountID(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// History - Returns an iterator over the history entries of the account,
+// of the account-id matching the filter, oldest first, it is empty if the,
+// account is not initialized. The ids generated by the executer are only,
+// iterated with the transaction ids enabled, as they are random unless,
+// requested, as in the account queries.
+func (exe *Executer) History(id AccountID, f account.Filter) iter.Seq[account.Entry] {
+	var acn *account.Account
+	var generated map[string]bool
+	if s := exe.slot(string(id), false); s != nil {
+		acn, generated = s.history()
+	}
+	exe.mu.RLock()
+	transactionIDs := exe.transactionIDs
+	exe.mu.RUnlock()
+	if transactionIDs || len(generated) == 0 {
+		return acn.History(f)
+	}
+
+	return func(yield func(account.Entry) bool) {
+		for e := range acn.History(f) {
+			if generated[e.ID] {
+				e.ID = ""
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// get - Returns the account of the slot, waiting for its lock.
+func (s *slot) get() *account.Account {
+	s.acquire(context.Background())
+	defer s.release()
+	return s.account
+}
+
+// history - Returns the account of the slot and a copy of the ids it,
+// generated, waiting for its lock.
+func (s *slot) history() (*account.Account, map[string]bool) {
+	s.acquire(context.Background())
+	defer s.release()
+	generated := make(map[string]bool, len(s.generated))
+	for id := range s.generated {
+		generated[id] = true
+	}
+
+	return s.account, generated
+}
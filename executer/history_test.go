@@ -0,0 +1,88 @@
+// ********************************************************************
+// * history_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds generated ids scenario, JR                       *
+// *                                                                  *
+// * This file contains all unit testing related with the accounts    *
+// * histories read from the executer.                                *
+// *                                                                  *
+// * Usage: go test -v -run History ./executer                        *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Test the initialized accounts are listed sorted.
+func TestHistoryAccounts(t *testing.T) {
+	ctx := context.Background()
+	exe := Init()
+	assert := assert.New(t)
+
+	assert.Empty(exe.Accounts())
+	for _, id := range []AccountID{"b", "a"} {
+		exe.CreateAccount(ctx, id, AccountSettings{Active: true, Limit: 100})
+	}
+	// Operations over accounts not initialized do not list them.
+	exe.Authorize(ctx, "c", ttransaction("t1", 0))
+	assert.Equal([]AccountID{"a", "b"}, exe.Accounts())
+}
+
+// Test the history of an account-id, filtered.
+func TestHistoryEntries(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(0)
+	exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	exe.Authorize(ctx, "a1", ttransaction("t2", 10))
+	exe.Authorize(ctx, "a2", ttransaction("t3", 20))
+
+	ids := func(id AccountID, f account.Filter) []string {
+		ids := []string{}
+		for e := range exe.History(id, f) {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	assert := assert.New(t)
+	assert.Equal([]string{"t1", "t2"}, ids("a1", account.Filter{}))
+	assert.Equal([]string{"t3"}, ids("a2", account.Filter{Outcome: account.Approved}))
+	assert.Empty(ids("a2", account.Filter{Merchant: "Subway"}))
+	assert.Empty(ids("a3", account.Filter{}))
+}
+
+// Test the history ids generated by the executer, of approved and declined,
+// transactions, are only iterated with the transaction ids enabled.
+func TestHistoryGeneratedIDs(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(0)
+	exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	exe.Authorize(ctx, "a1", ttransaction("", 1))
+	declined := ttransaction("", 2)
+	declined.Amount = 500
+	exe.Authorize(ctx, "a1", declined)
+
+	ids := func() []string {
+		ids := []string{}
+		for e := range exe.History("a1", account.Filter{Declined: true}) {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	assert := assert.New(t)
+	assert.Equal([]string{"t1", "", ""}, ids(), "Expected only the given ids.")
+	exe.SetTransactionIDs(true)
+	generated := ids()
+	if assert.Len(generated, 3) {
+		assert.Equal("t1", generated[0])
+		assert.NotEmpty(generated[1], "Expected the approved generated id.")
+		assert.NotEmpty(generated[2], "Expected the declined generated id.")
+	}
+}
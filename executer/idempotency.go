@@ -0,0 +1,78 @@
+// ********************************************************************
+// * idempotency.go                                                   *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Keeps the decisions of the last transactions with an id, by      *
+// * account, so a retried transaction gets its original decision     *
+// * instead of being applied again.                                  *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetIdempotencySize(int)                                        *
+// ********************************************************************
+
+package executer
+
+import "slices"
+
+// Number of transaction ids kept by account, when no size is set.
+const defaultIdempotencySize = 1000
+
+// replays - Holds the decisions by transaction id, up to $size, the oldest,
+// id is dropped when a new one does not fit.
+type replays struct {
+	size      int
+	decisions map[string]Decision
+	ids       []string
+}
+
+// SetIdempotencySize - Changes the number of transaction ids kept by account,
+// 0 keeps the default size and a negative size disables it. It applies to,
+// the accounts created after it.
+func (exe *Executer) SetIdempotencySize(size int) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.idempotency = size
+}
+
+// newReplays - Returns the replays of a new account, nil if disabled.
+func (exe *Executer) newReplays() *replays {
+	exe.mu.RLock()
+	size := exe.idempotency
+	exe.mu.RUnlock()
+	if size < 0 {
+		return nil
+	}
+	if size == 0 {
+		size = defaultIdempotencySize
+	}
+
+	return &replays{size: size, decisions: map[string]Decision{}}
+}
+
+// get - Returns the decision of the transaction id, if it is kept.
+func (r *replays) get(id string) (Decision, bool) {
+	if r == nil || id == "" {
+		return Decision{}, false
+	}
+
+	d, ok := r.decisions[id]
+	// The caller can't change the kept violations.
+	d.Violations = slices.Clone(d.Violations)
+	return d, ok
+}
+
+// put - Keeps the decision of the transaction id.
+func (r *replays) put(id string, d Decision) {
+	if r == nil || id == "" {
+		return
+	}
+
+	if len(r.ids) == r.size {
+		delete(r.decisions, r.ids[0])
+		r.ids = r.ids[1:]
+	}
+	d.Violations = slices.Clone(d.Violations)
+	r.decisions[id] = d
+	r.ids = append(r.ids, id)
+}
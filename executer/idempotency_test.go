@@ -0,0 +1,118 @@
+// ********************************************************************
+// * idempotency_test.go                                              *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Checks replayed decisions are marked, JR              *
+// *                                                                  *
+// * This file contains all unit testing related with the replay of   *
+// * transactions by id.                                              *
+// *                                                                  *
+// * Usage: go test -v -run Idempotency ./executer                    *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// tidempotent - Returns an executer with accounts a1 and a2, with the,
+// idempotency size.
+func tidempotent(size int) *Executer {
+	exe := Init()
+	exe.SetIdempotencySize(size)
+	for _, id := range []AccountID{"a1", "a2"} {
+		exe.CreateAccount(context.Background(), id, AccountSettings{Active: true, Limit: 100})
+	}
+
+	return exe
+}
+
+// ttransaction - Returns a transaction with the id, $minute minutes after,
+// 10:00.
+func ttransaction(id string, minute int) account.Transaction {
+	return account.Transaction{
+		ID:       id,
+		Merchant: "Burger Queen",
+		Amount:   10,
+		Time:     fmt.Sprintf("2019-02-13T10:%02d:00.000Z", minute),
+	}
+}
+
+// Test a retried transaction returns the original decision, not applied again.
+func TestIdempotencyReplay(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(0)
+	assert := assert.New(t)
+
+	original, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	exe.Authorize(ctx, "a1", ttransaction("t2", 10))
+	replay, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	assert.False(original.Replayed)
+	assert.True(replay.Replayed, "Expected a replayed decision.")
+	original.Replayed = true
+	assert.Equal(original, replay, "Expected the original decision.")
+	assert.Equal(90, replay.Account.Limit, "Expected the original account state.")
+	assert.Equal(80, snapshotOf(exe)["a1"].Limit, "Expected the retry not applied.")
+
+	// Changing the returned decision does not change the kept one.
+	replay.Violations = append(replay.Violations, 4)
+	replay, _ = exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	assert.Equal(original, replay, "Expected the original decision.")
+
+	// Ids are kept by account.
+	d, _ := exe.Authorize(ctx, "a2", ttransaction("t1", 0))
+	assert.Equal(AccountID("a2"), d.AccountID)
+	assert.Equal(90, snapshotOf(exe)["a2"].Limit, "Expected the transaction applied.")
+
+	// Transactions without id are never replayed.
+	exe.Authorize(ctx, "a1", ttransaction("", 20))
+	d, _ = exe.Authorize(ctx, "a1", ttransaction("", 20))
+	assert.Equal([]string{"doubled-transaction"}, d.ViolationNames())
+}
+
+// Test a declined transaction is replayed declined, even if it would pass now.
+func TestIdempotencyReplayDeclined(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(0)
+	exe.UpdateAccount(ctx, "a1", account.Update{DeniedMerchants: []string{"Burger Queen"}})
+	original, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	exe.UpdateAccount(ctx, "a1", account.Update{DeniedMerchants: []string{}})
+
+	replay, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	assert := assert.New(t)
+	assert.Equal([]string{"account-blocked-merchant"}, replay.ViolationNames())
+	original.Replayed = true
+	assert.Equal(original, replay, "Expected the original decision.")
+}
+
+// Test the oldest ids are dropped once the size is reached.
+func TestIdempotencyBounded(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(2)
+	for i, id := range []string{"t1", "t2", "t3"} {
+		exe.Authorize(ctx, "a1", ttransaction(id, i*10))
+	}
+	assert := assert.New(t)
+	assert.Len(exe.slot("a1", false).replays.ids, 2, "Expected 2 ids kept.")
+
+	// t1 is checked again, so it is a doubled transaction now.
+	d, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	assert.Equal([]string{"doubled-transaction"}, d.ViolationNames())
+	d, _ = exe.Authorize(ctx, "a1", ttransaction("t3", 20))
+	assert.Empty(d.Violations, "Expected t3 replayed.")
+	assert.Equal(70, snapshotOf(exe)["a1"].Limit, "Expected same account limit.")
+}
+
+// Test a negative size disables the replay.
+func TestIdempotencyDisabled(t *testing.T) {
+	ctx := context.Background()
+	exe := tidempotent(-1)
+	exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	d, _ := exe.Authorize(ctx, "a1", ttransaction("t1", 0))
+	assert.Equal(t, []string{"doubled-transaction"}, d.ViolationNames())
+}
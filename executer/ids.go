@@ -0,0 +1,176 @@
+// ********************************************************************
+// * ids.go                                                           *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Tells generated ids apart from the given ones, JR     *
+// * 2026-10-19 Records generated ids of declined transactions, JR    *
+// *                                                                  *
+// * Generates the ids of transactions without one, and the           *
+// * authorization codes of approved transactions, randomly or        *
+// * seeded, so tests get the same ids on every run.                  *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetIDGenerator(executer.SeededIDs(seed))                       *
+// * e.SetTransactionIDs(true)                                        *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Characters of the authorization codes.
+const codeChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Length of the authorization codes.
+const codeLength = 6
+
+// IDGenerator - Generates transaction ids and authorization codes, $line,
+// is the number of the operation in the executer, from 1.
+type IDGenerator interface {
+	TransactionID(id AccountID, line int) string
+	AuthorizationCode(id AccountID, line int) string
+}
+
+// randomIDs - Generates random ids and codes.
+type randomIDs struct{}
+
+// seededIDs - Generates ids and codes from the seed, the account-id and,
+// the operation number, so the same input gets the same ones.
+type seededIDs struct {
+	seed int64
+}
+
+// RandomIDs - Returns the generator used by default.
+func RandomIDs() IDGenerator {
+	return randomIDs{}
+}
+
+// SeededIDs - Returns a generator with the same ids and codes for the,
+// same seed.
+func SeededIDs(seed int64) IDGenerator {
+	return seededIDs{seed: seed}
+}
+
+// SetIDGenerator - Changes the generator of transaction ids and,
+// authorization codes, nil restores the random one.
+func (exe *Executer) SetIDGenerator(g IDGenerator) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.ids = g
+}
+
+// SetTransactionIDs - Enables or disables the transaction id and,
+// authorization code output, for transactions.
+func (exe *Executer) SetTransactionIDs(enabled bool) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.transactionIDs = enabled
+}
+
+// generate - Records the id generated for a transaction, approved or,
+// declined, so it is told apart from the given ones in the account history.
+func (s *slot) generate(id string) {
+	if s.generated == nil {
+		s.generated = map[string]bool{}
+	}
+	s.generated[id] = true
+}
+
+// generatedIn - Returns the ids of the history generated by the executer.
+func (s *slot) generatedIn(history []account.Transaction) map[string]bool {
+	generated := map[string]bool{}
+	for _, tsn := range history {
+		if s.generated[tsn.ID] {
+			generated[tsn.ID] = true
+		}
+	}
+
+	return generated
+}
+
+// givenHistory - Returns the summary history of the query without the,
+// generated ids, as they are random unless requested.
+func (d Decision) givenHistory() []account.Transaction {
+	history := make([]account.Transaction, 0, len(d.Summary.History))
+	for _, tsn := range d.Summary.History {
+		if d.generated[tsn.ID] {
+			tsn.ID = ""
+		}
+		history = append(history, tsn)
+	}
+
+	return history
+}
+
+// idGenerator - Returns the generator in use.
+func (exe *Executer) idGenerator() IDGenerator {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	if exe.ids == nil {
+		return randomIDs{}
+	}
+
+	return exe.ids
+}
+
+// TransactionID - Returns a random uuid.
+func (randomIDs) TransactionID(AccountID, int) string {
+	var b [16]byte
+	rand.Read(b[:])
+	return uuid(b)
+}
+
+// AuthorizationCode - Returns a random code.
+func (randomIDs) AuthorizationCode(AccountID, int) string {
+	var b [codeLength]byte
+	rand.Read(b[:])
+	return code(b[:])
+}
+
+// TransactionID - Returns a uuid from the seed, account-id and line.
+func (g seededIDs) TransactionID(id AccountID, line int) string {
+	sum := g.sum("transaction-id", id, line)
+	var b [16]byte
+	copy(b[:], sum[:])
+	return uuid(b)
+}
+
+// AuthorizationCode - Returns a code from the seed, account-id and line.
+func (g seededIDs) AuthorizationCode(id AccountID, line int) string {
+	sum := g.sum("authorization-code", id, line)
+	return code(sum[:codeLength])
+}
+
+// sum - Returns the hash of the seed, operation number, the kind of value,
+// and account-id.
+func (g seededIDs) sum(kind string, id AccountID, line int) [sha256.Size]byte {
+	buf := binary.AppendVarint(nil, g.seed)
+	buf = binary.AppendVarint(buf, int64(line))
+	buf = append(buf, kind...)
+	buf = append(buf, 0)
+	buf = append(buf, id...)
+	return sha256.Sum256(buf)
+}
+
+// uuid - Returns the bytes formatted as a version 4 uuid.
+func uuid(b [16]byte) string {
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// code - Returns the bytes as an authorization code.
+func code(b []byte) string {
+	c := make([]byte, len(b))
+	for i := range b {
+		c[i] = codeChars[int(b[i])%len(codeChars)]
+	}
+
+	return string(c)
+}
@@ -0,0 +1,117 @@
+// ********************************************************************
+// * ids_test.go                                                      *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds generated ids in account queries test, JR        *
+// *                                                                  *
+// * This file contains all unit testing related with the transaction *
+// * ids and authorization codes.                                     *
+// *                                                                  *
+// * Usage: go test -v -run IDs ./executer                            *
+// ********************************************************************
+
+package executer
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+// Formats of the generated values.
+var (
+	tuuid = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	tcode = regexp.MustCompile(`^[0-9A-Z]{6}$`)
+)
+
+// Test generated values format and uniqueness, seeded ones repeat by seed.
+func TestIDsGenerators(t *testing.T) {
+	assert := assert.New(t)
+	for name, g := range map[string]IDGenerator{
+		"Random": RandomIDs(),
+		"Seeded": SeededIDs(7),
+	} {
+		seen := map[string]bool{}
+		for line := 1; line <= 1000; line++ {
+			for _, id := range []AccountID{"", "a1"} {
+				tid := g.TransactionID(id, line)
+				assert.Regexp(tuuid, tid, "%s: expected a uuid.", name)
+				assert.Regexp(tcode, g.AuthorizationCode(id, line), "%s: expected a code.", name)
+				assert.False(seen[tid], "%s: expected unique ids.", name)
+				seen[tid] = true
+			}
+		}
+	}
+
+	assert.Equal(SeededIDs(7).TransactionID("a1", 3), SeededIDs(7).TransactionID("a1", 3))
+	assert.Equal(SeededIDs(7).AuthorizationCode("a1", 3), SeededIDs(7).AuthorizationCode("a1", 3))
+	assert.NotEqual(SeededIDs(7).TransactionID("a1", 3), SeededIDs(8).TransactionID("a1", 3))
+}
+
+// Test transaction ids and authorization codes output.
+func TestIDsOutput(t *testing.T) {
+	exe := Init()
+	exe.SetIDGenerator(SeededIDs(1))
+	exe.SetTransactionIDs(true)
+	g := SeededIDs(1)
+
+	in := []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"id": "t3", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"transaction": {"id": "t4", "merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:05:00.000Z"}}`,
+		`{"transaction": {"id": "t3", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+	}
+	out := []string{
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 80}, "violations": [], "transaction-id": "%s", "authorization-code": "%s"}`,
+			g.TransactionID("", 2), g.AuthorizationCode("", 2)),
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 60}, "violations": [], "transaction-id": "t3", "authorization-code": "%s"}`,
+			g.AuthorizationCode("", 3)),
+		`{"account": {"active-card": true, "available-limit": 60}, "violations": ["blocked-merchant"], "transaction-id": "t4"}`,
+		// The retry gets the original id and code.
+		fmt.Sprintf(`{"account": {"active-card": true, "available-limit": 60}, "violations": [], "transaction-id": "t3", "authorization-code": "%s"}`,
+			g.AuthorizationCode("", 3)),
+	}
+
+	assert := assert.New(t)
+	for i, op := range in {
+		assert.Equal(out[i], exe.Exec(op), "Expected same output from execution.")
+	}
+}
+
+// Test transaction ids are not output by default.
+func TestIDsOutputDisabled(t *testing.T) {
+	exe := Init()
+	exe.Exec(`{"account": {"active-card": true, "available-limit": 100}}`)
+	assert.Equal(
+		t,
+		`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+		exe.Exec(`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`),
+		"Expected the original output.",
+	)
+}
+
+// Test the generated ids are only output in account queries if enabled.
+func TestIDsQueryHistory(t *testing.T) {
+	g := SeededIDs(1)
+	for enabled, id := range map[bool]string{
+		true:  fmt.Sprintf(`"id": "%s", `, g.TransactionID("", 2)),
+		false: "",
+	} {
+		exe := Init()
+		exe.SetIDGenerator(g)
+		exe.SetTransactionIDs(enabled)
+		exe.Exec(`{"account": {"active-card": true, "available-limit": 100}}`)
+		exe.Exec(`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`)
+		exe.Exec(`{"transaction": {"id": "t3", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`)
+		assert.Equal(
+			t,
+			`{"account": {"active-card": true, "available-limit": 60}, "account-summary": {"held-amount": 40, "history": [{`+id+
+				`"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}, {"id": "t3", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}]}, "violations": []}`,
+			exe.Exec(`{"account-query": {}}`),
+			"Transaction ids enabled: %t.", enabled,
+		)
+	}
+}
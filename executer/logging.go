@@ -0,0 +1,104 @@
+// ********************************************************************
+// * logging.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Fixes SetLogger lock order with operations, JR        *
+// *                                                                  *
+// * Structured logs of the operations run by the executer, ignored   *
+// * input as warnings, not audited operations as errors and every    *
+// * decision as debug, the accounts log their checks with the same   *
+// * logger.                                                          *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))       *
+// ********************************************************************
+
+package executer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Max input bytes logged of an ignored operation.
+const maxLoggedInput = 256
+
+// Logger of the executers without one, discarding every record.
+var discard = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// SetLogger - Changes the logger of the executer and its accounts, nil,
+// discards the records.
+func (exe *Executer) SetLogger(l *slog.Logger) {
+	exe.mu.Lock()
+	exe.logger = l
+	exe.mu.Unlock()
+	for _, s := range exe.slots() {
+		s.acquire(context.Background())
+		if s.account != nil {
+			// The logger set last, if another call changed it meanwhile.
+			exe.mu.RLock()
+			s.account.SetLogger(exe.logger)
+			exe.mu.RUnlock()
+		}
+		s.release()
+	}
+}
+
+// currentLogger - Returns the logger in use, a discarding one if not set.
+func (exe *Executer) currentLogger() *slog.Logger {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	if exe.logger == nil {
+		return discard
+	}
+
+	return exe.logger
+}
+
+// logIgnored - Logs as warning the input operation ignored by the error,
+// the input is not logged if it is empty.
+func (exe *Executer) logIgnored(ctx context.Context, op string, err error) {
+	op = strings.TrimSpace(op)
+	if len(op) > maxLoggedInput {
+		op = op[:maxLoggedInput] + "..."
+	}
+	attrs := []slog.Attr{slog.String("error", err.Error())}
+	if op != "" {
+		attrs = append(attrs, slog.String("input", op))
+	}
+	exe.currentLogger().LogAttrs(ctx, slog.LevelWarn, "operation ignored", attrs...)
+}
+
+// logDecision - Logs as debug the operation decision, and as error,
+// the audit error if any.
+func (exe *Executer) logDecision(ctx context.Context, operation string, d Decision, err error) {
+	logger := exe.currentLogger()
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "operation not audited",
+			slog.String("account-id", string(d.AccountID)),
+			slog.String("operation", operation),
+			slog.String("error", err.Error()),
+		)
+	}
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("account-id", string(d.AccountID)),
+		slog.String("operation", operation),
+		slog.Any("violations", d.ViolationNames()),
+	}
+	if d.Decision != "" {
+		attrs = append(attrs, slog.String("transaction-id", d.TransactionID),
+			slog.String("decision", d.Decision), slog.Int("score", d.Score),
+			slog.Bool("replayed", d.Replayed))
+	}
+	if d.Account != nil {
+		attrs = append(attrs, slog.Bool("active-card", d.Account.Active),
+			slog.Int("available-limit", d.Account.Limit))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "operation decided", attrs...)
+}
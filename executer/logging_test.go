@@ -0,0 +1,125 @@
+// ********************************************************************
+// * logging_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the logs of     *
+// * the operations run by the executer, and its accounts checks.     *
+// *                                                                  *
+// * Usage: go test -v -run Logs ./executer                           *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/audit"
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// tlogs - Returns the json records logged from $level while executing,
+// the operations.
+func tlogs(t *testing.T, level slog.Level, setup func(exe *Executer), ops ...string) []map[string]interface{} {
+	out := &bytes.Buffer{}
+	exe := Init()
+	exe.SetLogger(slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})))
+	if setup != nil {
+		setup(exe)
+	}
+	for _, op := range ops {
+		exe.Exec(op)
+	}
+
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// Test malformed and unknown operations are logged as warnings.
+func TestLogsIgnored(t *testing.T) {
+	records := tlogs(t, slog.LevelInfo, nil,
+		`{"account": {"active-card": tru`,
+		`{"unknown": {}}`,
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	)
+	assert := assert.New(t)
+	assert.Len(records, 2, "Expected only the ignored operations logged.")
+	for _, record := range records {
+		assert.Equal("WARN", record["level"])
+		assert.Equal("operation ignored", record["msg"])
+	}
+	assert.Equal(`{"account": {"active-card": tru`, records[0]["input"])
+	assert.Contains(records[0]["error"], "unexpected end of JSON input")
+	assert.Equal(ErrUnknownOperation.Error(), records[1]["error"])
+}
+
+// Test the long ignored input is cut.
+func TestLogsIgnoredInput(t *testing.T) {
+	records := tlogs(t, slog.LevelWarn, nil, strings.Repeat("x", 2*maxLoggedInput))
+	assert.Len(t, records[0]["input"], maxLoggedInput+len("..."))
+}
+
+// Test operations decisions, and accounts checks, are logged as debug.
+func TestLogsDecisions(t *testing.T) {
+	records := tlogs(t, slog.LevelDebug, nil,
+		`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+		`{"account-id": "a1", "transaction": {"id": "t1", "merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	)
+	assert := assert.New(t)
+	decided := []map[string]interface{}{}
+	checks := 0
+	for _, record := range records {
+		assert.Equal("DEBUG", record["level"])
+		switch record["msg"] {
+		case "operation decided":
+			decided = append(decided, record)
+		case "check":
+			checks++
+		}
+	}
+	assert.Equal(12, checks, "Expected the account checks logged.")
+	assert.Len(decided, 2)
+	assert.Equal("account", decided[0]["operation"])
+	assert.Equal("a1", decided[1]["account-id"])
+	assert.Equal("t1", decided[1]["transaction-id"])
+	assert.Equal("declined", decided[1]["decision"])
+	assert.Equal([]interface{}{"blocked-merchant"}, decided[1]["violations"])
+	assert.Equal(100.0, decided[1]["available-limit"])
+}
+
+// Test operations not audited are logged as errors.
+func TestLogsAuditError(t *testing.T) {
+	records := tlogs(t, slog.LevelError, func(exe *Executer) {
+		exe.SetAudit(audit.New(failing{}))
+	}, `{"account": {"active-card": true, "available-limit": 100}}`)
+	assert := assert.New(t)
+	assert.Len(records, 1)
+	assert.Equal("ERROR", records[0]["level"])
+	assert.Equal("operation not audited", records[0]["msg"])
+	assert.Contains(records[0]["error"], "disk full")
+}
+
+// Test the logger set after the accounts creation is used by them.
+func TestLogsSetLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	exe := Init()
+	exe.Exec(`{"account": {"active-card": true, "available-limit": 100}}`)
+	exe.SetLogger(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	exe.Exec(`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`)
+	assert.Contains(t, out.String(), "msg=check")
+}
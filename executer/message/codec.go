@@ -0,0 +1,90 @@
+// ********************************************************************
+// * codec.go                                                         *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Pluggable codecs of the messages streams, every format has a     *
+// * decoder reading operations messages, and an encoder writing the  *
+// * output ones: ndjson (json lines, the default), csv and msgpack.  *
+// *                                                                  *
+// * Usage:                                                           *
+// * dec, err := message.NewDecoder(message.CSV, reader)              *
+// * enc, err := message.NewEncoder(message.MsgPack, writer)          *
+// * msg, err := dec.Decode()                                         *
+// * enc.Encode(msg)                                                  *
+// ********************************************************************
+
+package message
+
+import (
+	"fmt"
+	"io"
+)
+
+// Formats of the messages streams.
+const (
+	NDJSON  = "ndjson"
+	CSV     = "csv"
+	MsgPack = "msgpack"
+)
+
+// Formats - Every format supported, the first one is the default.
+var Formats = []string{NDJSON, CSV, MsgPack}
+
+// Decoder - Reads operations messages from a stream, one by call,
+// returns io.EOF once the stream ends, a record that is not a valid,
+// operation returns an empty message and a *DecodeError, and the next,
+// call goes on with the next record. Any other error ends the stream.
+type Decoder interface {
+	Decode() (*Message, error)
+}
+
+// Encoder - Writes output messages to a stream, one by call.
+type Encoder interface {
+	Encode(msg *Message) error
+}
+
+// DecodeError - A record of the stream that is not a valid operation,
+// with its raw input.
+type DecodeError struct {
+	Input string
+	Err   error
+}
+
+// Error - Returns the decoding error message.
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - Returns the decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// NewDecoder - Returns the decoder of the format, reading from $r.
+func NewDecoder(format string, r io.Reader) (Decoder, error) {
+	switch format {
+	case NDJSON, "":
+		return NewNDJSONDecoder(r), nil
+	case CSV:
+		return NewCSVDecoder(r, nil), nil
+	case MsgPack:
+		return NewMsgPackDecoder(r), nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// NewEncoder - Returns the encoder of the format, writing to $w.
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case NDJSON, "":
+		return NewNDJSONEncoder(w), nil
+	case CSV:
+		return NewCSVEncoder(w), nil
+	case MsgPack:
+		return NewMsgPackEncoder(w), nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
@@ -0,0 +1,209 @@
+// ********************************************************************
+// * codec_test.go                                                    *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds protocol version cases, JR                       *
+// * 2026-10-19 Adds account-query case, JR                           *
+// * 2026-10-19 Adds card lock update case, JR                        *
+// * 2026-10-19 Adds card state and expiry cases, JR                  *
+// *                                                                  *
+// * This file contains all unit testing related with the messages    *
+// * codecs, every operation type round trip in every format.         *
+// *                                                                  *
+// * Usage: go test -v -run Codec ./executer/message                  *
+// ********************************************************************
+
+package message
+
+import (
+	"authorizer/account"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Test operations of every type.
+var toperations = map[string]*Message{
+	"Account": {
+		AccountID: "a1",
+		Account: &AccountMessage{
+			Active:           true,
+			Limit:            100,
+			AllowedMerchants: []string{"Burger Queen", "Habbib's"},
+			DeniedMerchants:  []string{"Burger King"},
+		},
+		Violations: []string{},
+	},
+	"AccountCard": {
+		Account:    &AccountMessage{Limit: 50, CardState: account.CardLost, CardExpiry: "2027-12"},
+		Violations: []string{},
+	},
+	"AccountNoLists": {
+		Account:    &AccountMessage{Limit: 50},
+		Violations: []string{},
+	},
+	"AccountUpdate": {
+		AccountID: "a1",
+		Update: &account.Update{
+			DeniedMerchants: []string{"Habbib's", "Burger, King"},
+		},
+		Violations: []string{},
+	},
+	"AccountUpdateLock": {
+		Update:     &account.Update{Locked: new(bool), CardState: account.CardActive, CardExpiry: "2027-12"},
+		Violations: []string{},
+	},
+	"AccountQuery": {
+		AccountID:  "a1",
+		Query:      &account.Query{Time: "2019-02-13T10:00:00.000Z", Window: 5},
+		Violations: []string{},
+	},
+	"Transaction": {
+		AccountID: "a2",
+		Transaction: &account.Transaction{
+			ID:       "t1",
+			Merchant: "Burger \"Queen\"",
+			Amount:   20,
+			Time:     "2019-02-13T10:00:00.000Z",
+		},
+		Violations: []string{},
+	},
+	"TransactionV2": {
+		Version:   V2,
+		AccountID: "a2",
+		Transaction: &account.Transaction{
+			Merchant: "Habbib's",
+			Amount:   -10,
+			Time:     "2019-02-13T10:01:00.000Z",
+		},
+		Violations: []string{},
+	},
+}
+
+// troundtrip - Returns the messages encoded and decoded in the format.
+func troundtrip(t *testing.T, format string, msgs ...*Message) []*Message {
+	out := &bytes.Buffer{}
+	enc, err := NewEncoder(format, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec, err := NewDecoder(format, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := []*Message{}
+	for {
+		msg, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return decoded
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, msg)
+	}
+}
+
+// Test every operation type is decoded as encoded, in every format.
+func TestCodecRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		for name, msg := range toperations {
+			t.Run(format+"/"+name, func(t *testing.T) {
+				decoded := troundtrip(t, format, msg, msg)
+				assert := assert.New(t)
+				assert.Len(decoded, 2, "Expected a message by encoded one.")
+				for _, d := range decoded {
+					// The json input is only kept to be validated.
+					d.raw = nil
+					assert.Equal(msg, d)
+				}
+			})
+		}
+	}
+}
+
+// Test output fields are encoded, but not taken as input.
+func TestCodecOutput(t *testing.T) {
+	output := New(&AccountMessage{Active: true, Limit: 80}, nil, []string{})
+	output.AccountID = "a1"
+	output.AddViolation(3)
+	output.AddViolation(6)
+	output.TransactionID = "t1"
+	output.AuthorizationCode = "A1B2C3"
+	output.SetDecision(account.Declined, 40)
+	output.Version = V2
+	output.Operation = Transaction
+	output.Replayed = true
+	output.Error = "unknown operation"
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			enc, _ := NewEncoder(format, out)
+			assert := assert.New(t)
+			assert.NoError(enc.Encode(output))
+			for _, field := range []string{"a1", "insufficient-limit", "blocked-merchant", "t1", "A1B2C3", "declined", "unknown operation"} {
+				assert.Contains(out.String(), field)
+			}
+
+			dec, _ := NewDecoder(format, out)
+			msg, err := dec.Decode()
+			assert.NoError(err)
+			assert.Equal(Account, msg.Type())
+			assert.Empty(msg.Violations)
+			assert.Empty(msg.TransactionID)
+			assert.Empty(msg.AuthorizationCode)
+			assert.Empty(msg.Decision)
+			assert.Nil(msg.Score)
+			assert.Empty(msg.Operation)
+			assert.False(msg.Replayed)
+			assert.Empty(msg.Error)
+			assert.Equal(V2, msg.Version)
+		})
+	}
+}
+
+// Test the json lines encoding is the original output.
+func TestCodecNDJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := NewNDJSONEncoder(out)
+	enc.Encode(New(nil, nil, []string{}))
+	enc.Encode(New(&AccountMessage{Active: true, Limit: 100}, nil, []string{"insufficient-limit"}))
+	assert.Equal(t, `{"account": {}, "violations": []}`+"\n"+
+		`{"account": {"active-card": true, "available-limit": 100}, "violations": ["insufficient-limit"]}`+"\n", out.String())
+}
+
+// Test malformed lines are decode errors, and the next ones decoded,
+// the last line may have no new line.
+func TestCodecNDJSONMalformed(t *testing.T) {
+	dec := NewNDJSONDecoder(strings.NewReader("{\"account\": {\"active-card\": tru\n\n" + `{"transaction": {"amount": 10}}`))
+	assert := assert.New(t)
+	for i := 0; i < 2; i++ {
+		msg, err := dec.Decode()
+		var decodeErr *DecodeError
+		assert.True(errors.As(err, &decodeErr), "Expected a decode error.")
+		assert.Equal("", msg.Type())
+	}
+	msg, err := dec.Decode()
+	assert.NoError(err)
+	assert.Equal(10, msg.Transaction.Amount)
+	_, err = dec.Decode()
+	assert.Equal(io.EOF, err)
+}
+
+// Test unknown formats.
+func TestCodecUnknownFormat(t *testing.T) {
+	_, err := NewDecoder("xml", strings.NewReader(""))
+	assert.Error(t, err)
+	_, err = NewEncoder("xml", io.Discard)
+	assert.Error(t, err)
+}
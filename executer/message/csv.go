@@ -0,0 +1,309 @@
+// ********************************************************************
+// * csv.go                                                           *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds version and v2 output columns, JR                *
+// * 2026-10-19 Adds account-query columns, JR                        *
+// * 2026-10-19 Adds card-locked column, JR                           *
+// * 2026-10-19 Adds card-state and card-expiry columns, JR           *
+// * 2026-10-19 Types output records by their operation, JR           *
+// *                                                                  *
+// * Codec of csv streams, the first record is a header naming the    *
+// * columns, a record by message. Columns are the json fields names, *
+// * with "type" for the operation, the header can be mapped to them, *
+// * columns not known are ignored. Lists are separated by "|", an    *
+// * empty cell is a list not set, so in csv account-update lists can *
+// * be replaced but not cleared. The account summary history is a    *
+// * json array.                                                      *
+// *                                                                  *
+// * Usage:                                                           *
+// * dec := message.NewCSVDecoder(file, mapping)                      *
+// * enc := message.NewCSVEncoder(os.Stdout)                          *
+// ********************************************************************
+
+package message
+
+import (
+	"authorizer/account"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Separator of the lists values inside a cell.
+const csvListSeparator = "|"
+
+// CSVColumns - Columns of the csv messages, in the order they are written.
+var CSVColumns = []string{
+	"version", "type", "operation", "account-id",
+	"active-card", "available-limit", "allowed-merchants", "denied-merchants",
+	"card-locked", "card-state", "card-expiry",
+	"id", "merchant", "amount", "time", "window",
+	"held-amount", "history", "violations", "transaction-id", "authorization-code", "decision", "risk-score",
+	"replayed", "error",
+}
+
+// CSVDecoder - Decodes an operation by record, with the columns named,
+// by the header.
+type CSVDecoder struct {
+	r       *csv.Reader
+	mapping map[string]string
+	columns []string
+}
+
+// CSVEncoder - Encodes an output message by record, after the header.
+type CSVEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVDecoder - Returns a csv decoder reading from $r, the $mapping,
+// renames the header columns to the messages ones (e.g. "op" to "type").
+func NewCSVDecoder(r io.Reader, mapping map[string]string) *CSVDecoder {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &CSVDecoder{r: reader, mapping: mapping}
+}
+
+// Decode - Returns the message of the next record, the header is read,
+// first.
+func (dec *CSVDecoder) Decode() (*Message, error) {
+	if dec.columns == nil {
+		header, err := dec.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		dec.columns = dec.header(header)
+	}
+
+	record, err := dec.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return New(nil, nil, []string{}), &DecodeError{Input: strings.Join(record, ","), Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cells := map[string]string{}
+	for i, value := range record {
+		if i < len(dec.columns) && dec.columns[i] != "" {
+			cells[dec.columns[i]] = value
+		}
+	}
+	msg, err := fromCells(cells)
+	if err != nil {
+		line, _ := dec.r.FieldPos(0)
+		return New(nil, nil, []string{}), &DecodeError{
+			Input: strings.Join(record, ","),
+			Err:   fmt.Errorf("record on line %d: %w", line, err),
+		}
+	}
+
+	return msg, nil
+}
+
+// header - Returns the message column of each header column, mapped,
+// empty for the columns not known.
+func (dec *CSVDecoder) header(header []string) []string {
+	columns := make([]string, len(header))
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark.
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if mapped, ok := dec.mapping[name]; ok {
+			name = mapped
+		}
+		for _, column := range CSVColumns {
+			if strings.EqualFold(name, column) {
+				columns[i] = column
+			}
+		}
+	}
+
+	return columns
+}
+
+// fromCells - Returns the operation message of the record cells by column,
+// records of an unknown type are messages with no operation.
+func fromCells(cells map[string]string) (*Message, error) {
+	msg := New(nil, nil, []string{})
+	msg.AccountID = cells["account-id"]
+	var err error
+	if msg.Version, err = parseInt(cells["version"]); err != nil {
+		return nil, err
+	}
+	if !SupportedVersion(msg.version()) {
+		return nil, fmt.Errorf("%w %d", ErrVersion, msg.Version)
+	}
+	switch cells["type"] {
+	case Account:
+		msg.Account = &AccountMessage{
+			AllowedMerchants: list(cells["allowed-merchants"]),
+			DeniedMerchants:  list(cells["denied-merchants"]),
+			CardState:        cells["card-state"],
+			CardExpiry:       cells["card-expiry"],
+		}
+		if msg.Account.Active, err = parseBool(cells["active-card"]); err != nil {
+			return nil, err
+		}
+		if msg.Account.Limit, err = parseInt(cells["available-limit"]); err != nil {
+			return nil, err
+		}
+	case AccountUpdate:
+		msg.Update = &account.Update{
+			AllowedMerchants: list(cells["allowed-merchants"]),
+			DeniedMerchants:  list(cells["denied-merchants"]),
+			CardState:        cells["card-state"],
+			CardExpiry:       cells["card-expiry"],
+		}
+		// An empty cell keeps the card lock.
+		if cells["card-locked"] != "" {
+			locked, err := parseBool(cells["card-locked"])
+			if err != nil {
+				return nil, err
+			}
+			msg.Update.Locked = &locked
+		}
+	case AccountQuery:
+		msg.Query = &account.Query{Time: cells["time"]}
+		if msg.Query.Window, err = parseInt(cells["window"]); err != nil {
+			return nil, err
+		}
+	case Transaction:
+		msg.Transaction = &account.Transaction{
+			ID:       cells["id"],
+			Merchant: cells["merchant"],
+			Time:     cells["time"],
+		}
+		if msg.Transaction.Amount, err = parseInt(cells["amount"]); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// NewCSVEncoder - Returns a csv encoder writing to $w.
+func NewCSVEncoder(w io.Writer) *CSVEncoder {
+	return &CSVEncoder{w: csv.NewWriter(w)}
+}
+
+// Encode - Writes the message record, and the header before the first one,
+// each record is flushed so it is read as soon as it is written.
+func (enc *CSVEncoder) Encode(msg *Message) error {
+	if !enc.header {
+		if err := enc.w.Write(CSVColumns); err != nil {
+			return err
+		}
+		enc.header = true
+	}
+
+	cells := toCells(msg)
+	record := make([]string, len(CSVColumns))
+	for i, column := range CSVColumns {
+		record[i] = cells[column]
+	}
+	if err := enc.w.Write(record); err != nil {
+		return err
+	}
+	enc.w.Flush()
+
+	return enc.w.Error()
+}
+
+// toCells - Returns the message fields set, by column.
+func toCells(msg *Message) map[string]string {
+	cells := map[string]string{
+		"type":               msg.Type(),
+		"operation":          msg.Operation,
+		"account-id":         msg.AccountID,
+		"violations":         strings.Join(msg.Violations, csvListSeparator),
+		"transaction-id":     msg.TransactionID,
+		"authorization-code": msg.AuthorizationCode,
+		"decision":           msg.Decision,
+		"error":              msg.Error,
+	}
+	if msg.output != "" {
+		cells["type"] = msg.output
+	}
+	if msg.Version != 0 {
+		cells["version"] = strconv.Itoa(msg.Version)
+	}
+	if msg.Replayed {
+		cells["replayed"] = strconv.FormatBool(msg.Replayed)
+	}
+	if msg.Account != nil {
+		cells["active-card"] = strconv.FormatBool(msg.Account.Active)
+		cells["available-limit"] = strconv.Itoa(msg.Account.Limit)
+		cells["allowed-merchants"] = strings.Join(msg.Account.AllowedMerchants, csvListSeparator)
+		cells["denied-merchants"] = strings.Join(msg.Account.DeniedMerchants, csvListSeparator)
+		if msg.Account.Locked {
+			cells["card-locked"] = strconv.FormatBool(msg.Account.Locked)
+		}
+		cells["card-state"] = msg.Account.CardState
+		cells["card-expiry"] = msg.Account.CardExpiry
+	}
+	if msg.Update != nil {
+		cells["allowed-merchants"] = strings.Join(msg.Update.AllowedMerchants, csvListSeparator)
+		cells["denied-merchants"] = strings.Join(msg.Update.DeniedMerchants, csvListSeparator)
+		if msg.Update.Locked != nil {
+			cells["card-locked"] = strconv.FormatBool(*msg.Update.Locked)
+		}
+		cells["card-state"] = msg.Update.CardState
+		cells["card-expiry"] = msg.Update.CardExpiry
+	}
+	if msg.Query != nil {
+		cells["time"] = msg.Query.Time
+		if msg.Query.Window != 0 {
+			cells["window"] = strconv.Itoa(msg.Query.Window)
+		}
+	}
+	if msg.Summary != nil {
+		cells["held-amount"] = strconv.Itoa(msg.Summary.Held)
+		history, _ := json.Marshal(msg.Summary.History)
+		cells["history"] = string(history)
+	}
+	if msg.Transaction != nil {
+		cells["id"] = msg.Transaction.ID
+		cells["merchant"] = msg.Transaction.Merchant
+		cells["amount"] = strconv.Itoa(msg.Transaction.Amount)
+		cells["time"] = msg.Transaction.Time
+	}
+	if msg.Score != nil {
+		cells["risk-score"] = strconv.Itoa(*msg.Score)
+	}
+
+	return cells
+}
+
+// list - Returns the values of a list cell, nil if it is empty.
+func list(cell string) []string {
+	if cell == "" {
+		return nil
+	}
+
+	return strings.Split(cell, csvListSeparator)
+}
+
+// parseBool - Returns the boolean of a cell, false if it is empty.
+func parseBool(cell string) (bool, error) {
+	if cell == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(cell)
+}
+
+// parseInt - Returns the integer of a cell, 0 if it is empty.
+func parseInt(cell string) (int, error) {
+	if cell == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(cell)
+}
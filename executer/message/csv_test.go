@@ -0,0 +1,122 @@
+// ********************************************************************
+// * csv_test.go                                                      *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds output records type test, JR                     *
+// *                                                                  *
+// * This file contains all unit testing related with the csv codec,  *
+// * its header mapping and records not decoded.                      *
+// *                                                                  *
+// * Usage: go test -v -run CSV ./executer/message                    *
+// ********************************************************************
+
+package message
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+// tdecodeAll - Returns the messages and errors decoded until the input ends,
+// or an error ends it.
+func tdecodeAll(dec Decoder) ([]*Message, []error) {
+	msgs := []*Message{}
+	errs := []error{}
+	for {
+		msg, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return msgs, errs
+		}
+		msgs = append(msgs, msg)
+		errs = append(errs, err)
+		if msg == nil {
+			return msgs, errs
+		}
+	}
+}
+
+// Test partner headers mapped, in any order, unknown columns ignored.
+func TestCSVHeaderMapping(t *testing.T) {
+	input := "\ufeffOperation, Store,Value,When,Channel,account-id\n" +
+		"transaction,Burger Queen,20,2019-02-13T10:00:00.000Z,web,a1\n" +
+		"TRANSACTION,Burger Queen,20,2019-02-13T10:00:00.000Z,web,a1\n"
+	dec := NewCSVDecoder(strings.NewReader(input), map[string]string{
+		"Operation": "type",
+		"Store":     "merchant",
+		"Value":     "amount",
+		"When":      "time",
+	})
+	msgs, errs := tdecodeAll(dec)
+
+	assert := assert.New(t)
+	assert.Equal([]error{nil, nil}, errs)
+	assert.Equal(Transaction, msgs[0].Type())
+	assert.Equal("a1", msgs[0].AccountID)
+	assert.Equal("Burger Queen", msgs[0].Transaction.Merchant)
+	assert.Equal(20, msgs[0].Transaction.Amount)
+	assert.Equal("2019-02-13T10:00:00.000Z", msgs[0].Transaction.Time)
+	assert.Equal("", msgs[1].Type(), "Expected types to be lower case.")
+}
+
+// Test records not decoded are errors, and the next ones decoded.
+func TestCSVMalformed(t *testing.T) {
+	input := "type,active-card,available-limit,amount\n" +
+		"account,yes,100,\n" +
+		"transaction,,,ten\n" +
+		"transaction,,,\"1\"0\n" +
+		"account,true,100,\n"
+	msgs, errs := tdecodeAll(NewCSVDecoder(strings.NewReader(input), nil))
+
+	assert := assert.New(t)
+	assert.Len(msgs, 4)
+	for i, err := range errs[:3] {
+		var decodeErr *DecodeError
+		assert.True(errors.As(err, &decodeErr), "Expected a decode error on record %d.", i+1)
+		assert.Equal("", msgs[i].Type())
+	}
+	assert.Contains(errs[0].Error(), "line 2")
+	assert.Equal("transaction,,,ten", errs[1].(*DecodeError).Input)
+	assert.NoError(errs[3])
+	assert.Equal(100, msgs[3].Account.Limit)
+}
+
+// Test the header is written once, before the first record.
+func TestCSVEncoder(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := NewCSVEncoder(out)
+	enc.Encode(New(nil, nil, []string{}))
+	enc.Encode(New(&AccountMessage{Active: true, Limit: 100}, nil, []string{"insufficient-limit", "blocked-merchant"}))
+
+	assert.Equal(t, strings.Join(CSVColumns, ",")+"\n"+
+		",,,,,,,,,,,,,,,,,,,,,,,,\n"+
+		",account,,,true,100,,,,,,,,,,,,,insufficient-limit|blocked-merchant,,,,,,\n", out.String())
+}
+
+// Test output records are typed by the operation they are the output of.
+func TestCSVEncoderOutputType(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := NewCSVEncoder(out)
+	msg := New(&AccountMessage{Active: true, Limit: 80}, nil, []string{})
+	msg.SetOutput(Transaction)
+	enc.Encode(msg)
+	enc.Encode(New(&AccountMessage{Active: true, Limit: 80}, nil, []string{}))
+
+	records, err := csv.NewReader(out).ReadAll()
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.Equal(Transaction, records[1][1])
+	assert.Equal(Account, records[2][1])
+}
+
+// Test an empty input has no messages, even without header.
+func TestCSVEmpty(t *testing.T) {
+	for _, input := range []string{"", "type,amount\n"} {
+		msgs, _ := tdecodeAll(NewCSVDecoder(strings.NewReader(input), nil))
+		assert.Empty(t, msgs)
+	}
+}
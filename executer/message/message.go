@@ -2,6 +2,18 @@
 // * message.go                                                       *
 // *                                                                  *
 // * 2020-03-15 First Version, JR                                     *
+// * 2026-10-19 Adds account-update message, JR                       *
+// * 2026-10-19 Adds decision and risk score, JR                      *
+// * 2026-10-19 Adds Decode, ignoring unknown operations, JR          *
+// * 2026-10-19 Adds account-id, JR                                   *
+// * 2026-10-19 Adds transaction id and authorization code, JR        *
+// * 2026-10-19 Adds Encode, moved from the executer, JR              *
+// * 2026-10-19 Adds protocol version and v2 output fields, JR        *
+// * 2026-10-19 Adds account-query message, JR                        *
+// * 2026-10-19 Adds card lock, JR                                    *
+// * 2026-10-19 Adds card state and expiry, JR                        *
+// * 2026-10-19 Adds operation of output messages, JR                 *
+// * 2026-10-19 Keeps strings values as is on Encode, JR              *
 // *                                                                  *
 // * This package serves as container in memory for json strings,     *
 // * the message struct contains all the fields required to keep,     *
@@ -9,6 +21,8 @@
 // *                                                                  *
 // * Usage:                                                           *
 // * msg := message.New(Account, Transaction, Violations)             *
+// * msg, err := message.Decode(line)                                 *
+// * line := message.Encode(msg)                                      *
 // * msg.Type()                                                       *
 // * msg.AddViolation(Code)                                           *
 // ********************************************************************
@@ -17,40 +31,208 @@ package message
 
 import (
 	"authorizer/account"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
 )
 
 // Type of messages.
 const (
-	Account     = "account"
-	Transaction = "transaction"
+	Account       = "account"
+	AccountUpdate = "account-update"
+	AccountQuery  = "account-query"
+	Transaction   = "transaction"
 )
 
-// Message - Represents json output line while is in memory.
+// Message - Represents json output line while is in memory, the account-id,
+// is optional while working with a single account, and the version, while,
+// working with v1. The operation, replayed and error fields are only output,
+// in v2, the account summary only for account queries, raw keeps the json,
+// input the message was decoded from, and output the operation an output,
+// message is of, in any version.
 type Message struct {
-	Account     *AccountMessage      `json:"account"`
-	Transaction *account.Transaction `json:"transaction,omitempty"`
-	Violations  []string             `json:"violations"`
+	Version           int                  `json:"version,omitempty"`
+	Operation         string               `json:"operation,omitempty"`
+	AccountID         string               `json:"account-id,omitempty"`
+	Account           *AccountMessage      `json:"account"`
+	Summary           *SummaryMessage      `json:"account-summary,omitempty"`
+	Update            *account.Update      `json:"account-update,omitempty"`
+	Query             *account.Query       `json:"account-query,omitempty"`
+	Transaction       *account.Transaction `json:"transaction,omitempty"`
+	Violations        []string             `json:"violations"`
+	TransactionID     string               `json:"transaction-id,omitempty"`
+	AuthorizationCode string               `json:"authorization-code,omitempty"`
+	Decision          string               `json:"decision,omitempty"`
+	Score             *int                 `json:"risk-score,omitempty"`
+	Replayed          bool                 `json:"replayed,omitempty"`
+	Error             string               `json:"error,omitempty"`
+	raw               []byte
+	output            string
 }
 
-// AccountMessage - represents account fields gotten from json input.
+// AccountMessage - represents account fields gotten from json input, the,
+// card lock is only output, while the card is locked. The card state takes,
+// precedence over the active flag on input, and it is only output for,
+// states other than active and inactive.
 type AccountMessage struct {
-	Active bool `json:"active-card"`
-	Limit  int  `json:"available-limit"`
+	Active           bool     `json:"active-card"`
+	Limit            int      `json:"available-limit"`
+	AllowedMerchants []string `json:"allowed-merchants,omitempty"`
+	DeniedMerchants  []string `json:"denied-merchants,omitempty"`
+	Locked           bool     `json:"card-locked,omitempty"`
+	CardState        string   `json:"card-state,omitempty"`
+	CardExpiry       string   `json:"card-expiry,omitempty"`
+}
+
+// SummaryMessage - represents the account summary of an account query,
+// the history is never null.
+type SummaryMessage struct {
+	Held    int                   `json:"held-amount"`
+	History []account.Transaction `json:"history"`
 }
 
 // New - Returns a new empty ready to be constructed with executer process.
 func New(a *AccountMessage, t *account.Transaction, v []string) *Message {
-	return &Message{a, t, v}
+	return &Message{Account: a, Transaction: t, Violations: v}
+}
+
+// Decode - Returns the message from a json operation line, output fields,
+// are not taken from the line. If the line is not a valid json operation,
+// or its version is not supported, an empty message is returned with,
+// the error.
+func Decode(op string) (*Message, error) {
+	msg := New(nil, nil, []string{})
+	if err := json.Unmarshal([]byte(op), msg); err != nil {
+		return New(nil, nil, []string{}), err
+	}
+	if err := msg.input(); err != nil {
+		return New(nil, nil, []string{}), err
+	}
+
+	msg.raw = []byte(op)
+	return msg, nil
+}
+
+// operation - Message of an account-update or transaction operation,
+// encoded without account.
+type operation struct {
+	*Message
+	Account *AccountMessage `json:"account,omitempty"`
+}
+
+// Encode - Returns the json output line of the message, with a space,
+// after each ":" and ",", and empty objects instead of null refs.
+// Operations messages other than account are encoded without account,
+// so they are decoded as encoded, as v2 outputs of accounts not initialized.
+func Encode(msg *Message) string {
+	// Converting to json.
+	var output []byte
+	if msg.Account == nil && (msg.Type() != "" || msg.Version >= V2) {
+		output, _ = json.Marshal(operation{Message: msg})
+	} else {
+		output, _ = json.Marshal(msg)
+	}
+	// Prettify with no null refs and spaces.
+	return prettify(output)
+}
+
+// prettify - Returns the json output with a space after each ":" and ",",
+// and empty objects instead of null refs, leaving strings values as is.
+func prettify(output []byte) string {
+	var result strings.Builder
+	inString := false
+	escaped := false
+	for i := 0; i < len(output); i++ {
+		c := output[i]
+		switch {
+		case inString:
+			result.WriteByte(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			result.WriteByte(c)
+		case c == ':' || c == ',':
+			result.WriteByte(c)
+			result.WriteByte(' ')
+		case bytes.HasPrefix(output[i:], []byte("null")):
+			result.WriteString("{}")
+			i += len("null") - 1
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return result.String()
+}
+
+// input - Clears the output fields of a decoded operation message, and,
+// returns an ErrVersion error if its version is not supported.
+func (msg *Message) input() error {
+	msg.Operation = ""
+	msg.Summary = nil
+	msg.Violations = []string{}
+	msg.TransactionID = ""
+	msg.AuthorizationCode = ""
+	msg.Decision = ""
+	msg.Score = nil
+	msg.Replayed = false
+	msg.Error = ""
+	if !SupportedVersion(msg.version()) {
+		return fmt.Errorf("%w %d", ErrVersion, msg.Version)
+	}
+
+	return nil
+}
+
+// version - Returns the protocol version of the message, v1 when not set.
+func (msg *Message) version() int {
+	if msg.Version == 0 {
+		return V1
+	}
+
+	return msg.Version
+}
+
+// SetOutput - Marks the message as the output of the operation, its csv,
+// type, as output messages carry the account whatever the operation.
+func (msg *Message) SetOutput(operation string) {
+	msg.output = operation
 }
 
 // Type returns a string to identify the operation type
-// "account" or "transaction"
+// "account", "account-update", "account-query" or "transaction", and an,
+// empty string for unknown operations.
 func (msg *Message) Type() string {
 	if msg.Account != nil {
 		return Account
 	}
 
-	return Transaction
+	if msg.Update != nil {
+		return AccountUpdate
+	}
+
+	if msg.Query != nil {
+		return AccountQuery
+	}
+
+	if msg.Transaction != nil {
+		return Transaction
+	}
+
+	return ""
+}
+
+// SetDecision - sets the transaction decision and its aggregate risk score.
+func (msg *Message) SetDecision(decision string, score int) {
+	msg.Decision = decision
+	msg.Score = &score
 }
 
 // AddViolation - adds a new violation message to the array, accordignly the,
@@ -2,22 +2,35 @@
 // * message_test.go                                                  *
 // *                                                                  *
 // * 2020-03-15 First Version, JR                                     *
+// * 2026-10-19 Adds decision scenario, JR                            *
+// * 2026-10-19 Adds decode scenarios and fuzz test, JR               *
+// * 2026-10-19 Adds json decode and encode benchmarks, JR            *
+// * 2026-10-19 Adds account-query type to the fuzz test, JR          *
+// * 2026-10-19 Adds unknown operations decode scenario, JR           *
+// * 2026-10-19 Adds Encode strings values scenario, JR               *
+// * 2026-10-19 Benchmarks Encode instead of json.Marshal, JR         *
 // *                                                                  *
 // * This file contains all unit test related with message operations.*
 // *                                                                  *
 // * Usage: go test -v ./executer/message                             *
+// * Fuzzing: go test -fuzz FuzzDecode ./executer/message             *
+// * Benchmarks: go test -run XXX -bench . ./executer/message         *
 // ********************************************************************
 
 package message
 
 import (
 	"authorizer/account"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 // Max int code value allowed to violations references.
-const maxValidCode = 6
+const maxValidCode = 19
 
 // Test Messages to cover our unit testing.
 var tmsgs = map[string]*Message{
@@ -33,6 +46,12 @@ var tmsgs = map[string]*Message{
 		},
 	},
 
+	"Update": {
+		Update: &account.Update{
+			DeniedMerchants: []string{"Fulanito"},
+		},
+	},
+
 	"Transaction": {
 		Transaction: &account.Transaction{
 			Merchant: "Fulanito",
@@ -63,6 +82,70 @@ func TestAccountMessage(t *testing.T) {
 	)
 }
 
+// Test if a message is of type "account-update".
+func TestAccountUpdateMessage(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(
+		AccountUpdate,
+		tmsgs["Update"].Type(),
+		"Expected an account update type message.",
+	)
+}
+
+// Test if a message is of unknown type.
+func TestUnknownMessage(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(
+		"",
+		tmsgs["Sample"].Type(),
+		"Expected an unknown type message.",
+	)
+}
+
+// Test decode of a json operation line.
+func TestDecode(t *testing.T) {
+	msg, err := Decode(`{"transaction": {"merchant": "Fulanito", "amount": 100, "time": "2019-02-13T10:00:00.000Z"}, "violations": ["doubled-transaction"]}`)
+	assert := assert.New(t)
+	assert.Nil(err, "Expected no error.")
+	assert.Equal(tmsgs["Transaction"].Transaction, msg.Transaction, "Expected same transaction.")
+	assert.Equal([]string{}, msg.Violations, "Expected violations not taken from input.")
+}
+
+// Test decode of valid json lines of unknown operations, they are not,
+// errors, only of unknown type.
+func TestDecodeUnknownOperation(t *testing.T) {
+	assert := assert.New(t)
+	for _, op := range []string{`{"refund": {"merchant": "Fulanito", "amount": 100}}`, `{}`} {
+		msg, err := Decode(op)
+		assert.NoError(err, "Decoding %s.", op)
+		assert.Equal("", msg.Type(), "Expected an unknown type message.")
+	}
+}
+
+// Test encode leaves strings values as is, only separators and null refs,
+// outside them are changed.
+func TestEncodeStrings(t *testing.T) {
+	value := `Burger: Queen, null "x" \`
+	msg := New(nil, nil, []string{value})
+	msg.AccountID = "a1,null"
+	output := Encode(msg)
+
+	assert := assert.New(t)
+	assert.Equal(`{"account-id": "a1,null", "account": {}, "violations": ["Burger: Queen, null \"x\" \\"]}`, output)
+	decoded := &Message{}
+	assert.NoError(json.Unmarshal([]byte(output), decoded))
+	assert.Equal([]string{value}, decoded.Violations)
+	assert.Equal("a1,null", decoded.AccountID)
+}
+
+// Test decode of a malformed json operation line.
+func TestDecodeMalformed(t *testing.T) {
+	msg, err := Decode(`{"transaction": {"amount": "100"}}`)
+	assert := assert.New(t)
+	assert.NotNil(err, "Expected an error.")
+	assert.Equal("", msg.Type(), "Expected an unknown type message.")
+}
+
 // Test if a message is of type "transaction".
 func TestTransactionMessage(t *testing.T) {
 	assert := assert.New(t)
@@ -94,3 +177,65 @@ func TestAddValidViolationCode(t *testing.T) {
 		"Expected array with violation associated string message.",
 	)
 }
+
+// Test if decision and risk score are set to the message.
+func TestSetDecision(t *testing.T) {
+	msg := New(nil, nil, []string{})
+	msg.SetDecision(account.ApprovedWithFlags, 30)
+	assert := assert.New(t)
+	assert.Equal(account.ApprovedWithFlags, msg.Decision, "Expected decision.")
+	assert.Equal(30, *msg.Score, "Expected risk score.")
+}
+
+// Fuzz decode of json operation lines, seeded with e2e inputs.
+func FuzzDecode(f *testing.F) {
+	ins, _ := filepath.Glob(filepath.Join("..", "..", "test", "*", "in"))
+	for _, in := range ins {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			f.Fatal(err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			f.Add(line)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, op string) {
+		msg, err := Decode(op)
+		if msg == nil {
+			t.Fatal("Expected a message.")
+		}
+		if err != nil && msg.Type() != "" {
+			t.Fatalf("Expected unknown type for malformed %q.", op)
+		}
+		switch msg.Type() {
+		case "", Account, AccountUpdate, AccountQuery, Transaction:
+		default:
+			t.Fatalf("Unexpected type %q.", msg.Type())
+		}
+		if len(msg.Violations) != 0 || msg.Decision != "" || msg.Score != nil || msg.Summary != nil {
+			t.Fatalf("Expected output fields not taken from %q.", op)
+		}
+		if _, err := json.Marshal(msg); err != nil {
+			t.Fatalf("Expected message encoded: %v", err)
+		}
+	})
+}
+
+// Benchmark decode of a transaction operation line.
+func BenchmarkDecode(b *testing.B) {
+	op := `{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Decode(op)
+	}
+}
+
+// Benchmark encode of an output message.
+func BenchmarkEncode(b *testing.B) {
+	msg := New(tmsgs["Account"].Account, nil, []string{account.Violations[4]})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Encode(msg)
+	}
+}
@@ -0,0 +1,104 @@
+// ********************************************************************
+// * msgpack.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Keeps the json form of values to be validated, JR     *
+// *                                                                  *
+// * Codec of msgpack streams, a map by message one after another,    *
+// * with the same keys as the json messages.                         *
+// *                                                                  *
+// * Usage:                                                           *
+// * dec := message.NewMsgPackDecoder(conn)                           *
+// * enc := message.NewMsgPackEncoder(conn)                           *
+// ********************************************************************
+
+package message
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+)
+
+// Struct tag the msgpack keys are taken from.
+const msgpackTag = "json"
+
+// MsgPackDecoder - Decodes an operation by msgpack value.
+type MsgPackDecoder struct {
+	r   *msgpack.Decoder
+	raw *msgpack.Decoder
+}
+
+// MsgPackEncoder - Encodes an output message by msgpack value.
+type MsgPackEncoder struct {
+	w *msgpack.Encoder
+}
+
+// NewMsgPackDecoder - Returns a msgpack decoder reading from $r.
+func NewMsgPackDecoder(r io.Reader) *MsgPackDecoder {
+	raw := msgpack.NewDecoder(nil)
+	raw.SetCustomStructTag(msgpackTag)
+	return &MsgPackDecoder{r: msgpack.NewDecoder(r), raw: raw}
+}
+
+// Decode - Returns the message of the next value, a value that is not,
+// an operation map is skipped, while a value not well formed ends the,
+// stream as the next one can not be found.
+func (dec *MsgPackDecoder) Decode() (*Message, error) {
+	// The stream ends between values, within a value it is truncated.
+	if _, err := dec.r.PeekCode(); err != nil {
+		return nil, err
+	}
+	value, err := dec.r.DecodeRaw()
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg := New(nil, nil, []string{})
+	dec.raw.ResetReader(bytes.NewReader(value))
+	err = dec.raw.Decode(msg)
+	if err == nil {
+		err = msg.input()
+	}
+	if err != nil {
+		return New(nil, nil, []string{}), &DecodeError{Input: hex.EncodeToString(value), Err: err}
+	}
+	msg.raw = dec.json(value)
+
+	return msg, nil
+}
+
+// json - Returns the msgpack value as json, to be validated as the json,
+// input, nil if it has no json form (e.g. binary strings or maps with,
+// no string keys).
+func (dec *MsgPackDecoder) json(value []byte) []byte {
+	var doc interface{}
+	dec.raw.ResetReader(bytes.NewReader(value))
+	if err := dec.raw.Decode(&doc); err != nil {
+		return nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// NewMsgPackEncoder - Returns a msgpack encoder writing to $w.
+func NewMsgPackEncoder(w io.Writer) *MsgPackEncoder {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag(msgpackTag)
+	return &MsgPackEncoder{w: enc}
+}
+
+// Encode - Writes the message msgpack map.
+func (enc *MsgPackEncoder) Encode(msg *Message) error {
+	return enc.w.Encode(msg)
+}
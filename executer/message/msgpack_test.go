@@ -0,0 +1,58 @@
+// ********************************************************************
+// * msgpack_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the msgpack     *
+// * codec, its keys and values not decoded.                          *
+// *                                                                  *
+// * Usage: go test -v -run MsgPack ./executer/message                *
+// ********************************************************************
+
+package message
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"testing"
+)
+
+// Test the msgpack keys are the json ones.
+func TestMsgPackKeys(t *testing.T) {
+	out := &bytes.Buffer{}
+	NewMsgPackEncoder(out).Encode(New(&AccountMessage{Active: true, Limit: 100}, nil, []string{}))
+	decoded := map[string]interface{}{}
+	assert := assert.New(t)
+	assert.NoError(msgpack.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(map[string]interface{}{
+		"account":    map[string]interface{}{"active-card": true, "available-limit": int8(100)},
+		"violations": []interface{}{},
+	}, decoded)
+}
+
+// Test values that are not operations are errors, and the next ones,
+// decoded, while a truncated value ends the stream.
+func TestMsgPackMalformed(t *testing.T) {
+	in := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(in)
+	enc.Encode("account")
+	enc.Encode(map[string]interface{}{"transaction": map[string]interface{}{"amount": "ten"}})
+	enc.Encode(map[string]interface{}{"transaction": map[string]interface{}{"amount": 10}})
+	in.Write([]byte{0x81, 0xa7})
+	msgs, errs := tdecodeAll(NewMsgPackDecoder(in))
+
+	assert := assert.New(t)
+	assert.Len(msgs, 4)
+	for i, err := range errs[:2] {
+		var decodeErr *DecodeError
+		assert.True(errors.As(err, &decodeErr), "Expected a decode error on value %d.", i+1)
+		assert.Equal("", msgs[i].Type())
+	}
+	assert.NoError(errs[2])
+	assert.Equal(10, msgs[2].Transaction.Amount)
+	assert.Nil(msgs[3], "Expected the stream ended.")
+	assert.True(errors.Is(errs[3], io.ErrUnexpectedEOF), "Expected a truncated value error, got %v.", errs[3])
+}
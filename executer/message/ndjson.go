@@ -0,0 +1,65 @@
+// ********************************************************************
+// * ndjson.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Codec of json lines streams, the original format, an operation   *
+// * by input line and an output line by operation.                   *
+// *                                                                  *
+// * Usage:                                                           *
+// * dec := message.NewNDJSONDecoder(os.Stdin)                        *
+// * enc := message.NewNDJSONEncoder(os.Stdout)                       *
+// ********************************************************************
+
+package message
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// NDJSONDecoder - Decodes an operation by line.
+type NDJSONDecoder struct {
+	r *bufio.Reader
+}
+
+// NDJSONEncoder - Encodes an output message by line.
+type NDJSONEncoder struct {
+	w io.Writer
+}
+
+// NewNDJSONDecoder - Returns a json lines decoder reading from $r.
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	return &NDJSONDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode - Returns the message of the next line, the input ends with,
+// the first empty read, a last line without new line is still decoded.
+func (dec *NDJSONDecoder) Decode() (*Message, error) {
+	line, err := dec.r.ReadString('\n')
+	if line == "" {
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+
+	msg, err := Decode(line)
+	if err != nil {
+		return msg, &DecodeError{Input: line, Err: err}
+	}
+
+	return msg, nil
+}
+
+// NewNDJSONEncoder - Returns a json lines encoder writing to $w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{w: w}
+}
+
+// Encode - Writes the message json output line.
+func (enc *NDJSONEncoder) Encode(msg *Message) error {
+	_, err := fmt.Fprintln(enc.w, Encode(msg))
+	return err
+}
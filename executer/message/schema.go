@@ -0,0 +1,228 @@
+// ********************************************************************
+// * schema.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds account-query schemas, JR                        *
+// *                                                                  *
+// * Versions of the protocol and their published json schemas, one   *
+// * by operation and one for the output, in ./schema/v$VERSION.      *
+// * Operations may set the version they are written in, v1 when not  *
+// * set, v1 output is the original format, v2 is a richer one.       *
+// *                                                                  *
+// * Usage:                                                           *
+// * schema, err := message.Schema(2, message.Transaction)            *
+// * err := message.Validate(msg)                                     *
+// ********************************************************************
+
+package message
+
+import (
+	"authorizer/account"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"sync"
+)
+
+// Protocol versions.
+const (
+	V1 = 1
+	V2 = 2
+)
+
+// Output - Name of the output messages schema.
+const Output = "output"
+
+// Versions - Protocol versions supported, the first one is the default.
+var Versions = []int{V1, V2}
+
+// Schemas - Names of the schemas of each version.
+var Schemas = []string{Account, AccountUpdate, AccountQuery, Transaction, Output}
+
+// ErrVersion - returned, wrapped, for operations of an unsupported version.
+var ErrVersion = errors.New("unsupported version")
+
+// ErrInvalid - returned, wrapped, for operations not valid against the,
+// schema of their version.
+var ErrInvalid = errors.New("invalid operation")
+
+// schemaFiles - Published schemas, by version.
+//
+//go:embed schema/v*/*.json
+var schemaFiles embed.FS
+
+// Base url of the schemas ids.
+const schemaURL = "https://github.com/juliocri/authorizer/schema/"
+
+// Compiled schemas by url, compiled once as they are first needed.
+var (
+	compileOnce sync.Once
+	compiled    map[string]*jsonschema.Schema
+	compileErr  error
+)
+
+// SupportedVersion - Returns true if the protocol version is supported.
+func SupportedVersion(version int) bool {
+	for _, v := range Versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Schema - Returns the json schema of the operation, or the output, in the,
+// protocol version.
+func Schema(version int, name string) ([]byte, error) {
+	if !SupportedVersion(version) {
+		return nil, fmt.Errorf("%w %d", ErrVersion, version)
+	}
+
+	schema, err := schemaFiles.ReadFile("schema/" + schemaName(version, name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+
+	return schema, nil
+}
+
+// Validate - Returns an ErrInvalid error if the operation message does not,
+// match the schema of its version. The json input it was decoded from,
+// is validated if there is one, so missing and unknown fields are found,
+// otherwise the operation fields it has, as csv records do not keep them.
+// Messages with no operation are not validated.
+func Validate(msg *Message) error {
+	if msg.Type() == "" {
+		return nil
+	}
+	doc := msg.raw
+	if doc == nil {
+		doc, _ = json.Marshal(inputMessage{
+			Version:     msg.Version,
+			AccountID:   msg.AccountID,
+			Account:     msg.Account,
+			Update:      msg.Update,
+			Query:       msg.Query,
+			Transaction: msg.Transaction,
+		})
+	}
+
+	return validate(msg.version(), msg.Type(), doc)
+}
+
+// ValidateOutput - Returns an ErrInvalid error if the json output line,
+// does not match the output schema of the protocol version.
+func ValidateOutput(version int, line string) error {
+	return validate(version, Output, []byte(line))
+}
+
+// inputMessage - Operation fields of a message, as they are validated.
+type inputMessage struct {
+	Version     int                  `json:"version,omitempty"`
+	AccountID   string               `json:"account-id,omitempty"`
+	Account     *AccountMessage      `json:"account,omitempty"`
+	Update      *account.Update      `json:"account-update,omitempty"`
+	Query       *account.Query       `json:"account-query,omitempty"`
+	Transaction *account.Transaction `json:"transaction,omitempty"`
+}
+
+// validate - Returns an ErrInvalid error if the json $doc does not match,
+// the schema $name of the version.
+func validate(version int, name string, doc []byte) error {
+	schema, err := compiledSchema(version, name)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := schema.Validate(value); err != nil {
+		var verr *jsonschema.ValidationError
+		if errors.As(err, &verr) {
+			return fmt.Errorf("%w: %s", ErrInvalid, reason(verr))
+		}
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	return nil
+}
+
+// reason - Returns the first leaf cause of the validation error, with the,
+// location of the value, as the whole tree is too long for a log line.
+func reason(verr *jsonschema.ValidationError) string {
+	for len(verr.Causes) > 0 {
+		verr = verr.Causes[0]
+	}
+	location := verr.InstanceLocation
+	if location == "" {
+		location = "/"
+	}
+
+	return location + ": " + verr.Message
+}
+
+// compiledSchema - Returns the compiled schema $name of the version, all,
+// the schemas are compiled the first time.
+func compiledSchema(version int, name string) (*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		compiled, compileErr = compileSchemas()
+	})
+	if compileErr != nil {
+		return nil, compileErr
+	}
+	if !SupportedVersion(version) {
+		return nil, fmt.Errorf("%w %d", ErrVersion, version)
+	}
+	schema, ok := compiled[schemaURL+schemaName(version, name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+
+	return schema, nil
+}
+
+// compileSchemas - Returns the embedded schemas compiled by url, formats,
+// as date-time are checked too.
+func compileSchemas() (map[string]*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	urls := []string{}
+	for _, version := range Versions {
+		for _, name := range Schemas {
+			data, err := schemaFiles.ReadFile("schema/" + schemaName(version, name))
+			if err != nil {
+				return nil, err
+			}
+			url := schemaURL + schemaName(version, name)
+			if err := c.AddResource(url, bytes.NewReader(data)); err != nil {
+				return nil, err
+			}
+			urls = append(urls, url)
+		}
+	}
+
+	schemas := map[string]*jsonschema.Schema{}
+	for _, url := range urls {
+		schema, err := c.Compile(url)
+		if err != nil {
+			return nil, err
+		}
+		schemas[url] = schema
+	}
+
+	return schemas, nil
+}
+
+// schemaName - Returns the file of the schema $name of the version, inside,
+// ./schema and the base url.
+func schemaName(version int, name string) string {
+	return fmt.Sprintf("v%d/%s.json", version, name)
+}
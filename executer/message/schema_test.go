@@ -0,0 +1,184 @@
+// ********************************************************************
+// * schema_test.go                                                   *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds card lock, state and expiry cases, JR            *
+// *                                                                  *
+// * This file contains all unit testing related with the protocol    *
+// * versions schemas, and the validation of the operations.          *
+// *                                                                  *
+// * Usage: go test -v -run Schema ./executer/message                 *
+// ********************************************************************
+
+package message
+
+import (
+	"authorizer/account"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Test every schema is published, with its version id.
+func TestSchemaFiles(t *testing.T) {
+	assert := assert.New(t)
+	for _, version := range Versions {
+		for _, name := range Schemas {
+			data, err := Schema(version, name)
+			assert.NoError(err)
+			schema := map[string]interface{}{}
+			assert.NoError(json.Unmarshal(data, &schema), "%d/%s", version, name)
+			assert.Equal(schemaURL+schemaName(version, name), schema["$id"])
+			_, err = compiledSchema(version, name)
+			assert.NoError(err)
+		}
+	}
+
+	_, err := Schema(3, Account)
+	assert.True(errors.Is(err, ErrVersion))
+	_, err = Schema(V1, "unknown")
+	assert.Error(err)
+}
+
+// Test the output schemas enumerate every violation name.
+func TestSchemaViolations(t *testing.T) {
+	names := []string{}
+	for _, name := range account.Violations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, version := range Versions {
+		data, _ := Schema(version, Output)
+		schema := struct {
+			Properties struct {
+				Violations struct {
+					Items struct {
+						Enum []string `json:"enum"`
+					} `json:"items"`
+				} `json:"violations"`
+			} `json:"properties"`
+		}{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		enum := schema.Properties.Violations.Items.Enum
+		sort.Strings(enum)
+		assert.Equal(t, names, enum, "v%d", version)
+	}
+}
+
+// Test json operations are validated against the schema of their version.
+func TestSchemaValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		op    string
+		valid bool
+	}{
+		{"Account", `{"account": {"active-card": true, "available-limit": 100}}`, true},
+		{"AccountV2", `{"version": 2, "account-id": "a1", "account": {"active-card": true, "available-limit": 100, "denied-merchants": ["Habbib's"]}}`, true},
+		{"AccountUpdate", `{"account-update": {"allowed-merchants": []}}`, true},
+		{"AccountUpdateLock", `{"version": 2, "account-update": {"card-locked": false}}`, true},
+		{"AccountCard", `{"account": {"active-card": true, "available-limit": 100, "card-state": "stolen", "card-expiry": "2027-12"}}`, true},
+		{"AccountUpdateCard", `{"account-update": {"card-state": "frozen"}}`, false},
+		{"AccountUpdateExpiry", `{"version": 2, "account-update": {"card-expiry": "12/27"}}`, false},
+		{"AccountQuery", `{"account-query": {}}`, true},
+		{"AccountQueryWindow", `{"version": 2, "account-query": {"time": "2019-02-13T10:00:00.000Z", "window": 10}}`, true},
+		{"AccountQueryTime", `{"account-query": {"time": "10:00"}}`, false},
+		{"Transaction", `{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`, true},
+		{"TransactionV1", `{"version": 1, "transaction": {"merchant": "Burger Queen", "amount": -20, "time": "2019-02-13T10:00:00Z"}}`, true},
+		{"MissingLimit", `{"account": {"active-card": true}}`, false},
+		{"MissingTime", `{"transaction": {"merchant": "Burger Queen", "amount": 20}}`, false},
+		{"EmptyMerchant", `{"transaction": {"merchant": "", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`, false},
+		{"DecimalAmount", `{"transaction": {"merchant": "Burger Queen", "amount": 20.5, "time": "2019-02-13T10:00:00.000Z"}}`, false},
+		{"Time", `{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "13/02/2019"}}`, false},
+		{"UnknownField", `{"account": {"active-card": true, "available-limit": 100, "limit": 100}}`, false},
+		{"TwoOperations", `{"account": {"active-card": true, "available-limit": 100}, "account-update": {}}`, false},
+		{"OutputField", `{"account-update": {}, "violations": []}`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Decode(tt.op)
+			if err != nil {
+				// Types not matching the message fail to decode already.
+				assert.False(t, tt.valid, err)
+				return
+			}
+			err = Validate(msg)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalid), err)
+			}
+		})
+	}
+}
+
+// Test the validation error names the value not valid.
+func TestSchemaValidateReason(t *testing.T) {
+	msg, _ := Decode(`{"version": 2, "transaction": {"merchant": "Burger Queen", "amount": 20}}`)
+	err := Validate(msg)
+	assert.EqualError(t, err, "invalid operation: /transaction: missing properties: 'time'")
+
+	msg, _ = Decode(`{"unknown": {}}`)
+	assert.NoError(t, Validate(msg), "Unknown operations are not validated.")
+}
+
+// Test unsupported versions are not decoded, in every format.
+func TestSchemaUnsupportedVersion(t *testing.T) {
+	_, err := Decode(`{"version": 3, "account": {"active-card": true, "available-limit": 100}}`)
+	assert.True(t, errors.Is(err, ErrVersion))
+
+	for _, format := range Formats {
+		out := &bytes.Buffer{}
+		enc, _ := NewEncoder(format, out)
+		enc.Encode(&Message{Version: -1, Account: &AccountMessage{Active: true}, Violations: []string{}})
+		dec, _ := NewDecoder(format, out)
+		msg, err := dec.Decode()
+		assert.True(t, errors.Is(err, ErrVersion), format)
+		assert.Equal(t, "", msg.Type())
+	}
+}
+
+// Test msgpack operations are validated with the fields given, and csv,
+// ones with the fields decoded.
+func TestSchemaValidateFormats(t *testing.T) {
+	value, _ := msgpack.Marshal(map[string]interface{}{
+		"transaction": map[string]interface{}{"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z", "currency": "BRL"},
+	})
+	msg, err := NewMsgPackDecoder(bytes.NewReader(value)).Decode()
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(Validate(msg), ErrInvalid), "Unknown msgpack fields are not valid.")
+
+	dec := NewCSVDecoder(strings.NewReader("type,merchant,amount,time\n"+
+		"transaction,Burger Queen,20,2019-02-13T10:00:00.000Z\n"+
+		"transaction,,20,2019-02-13T10:00:00.000Z\n"+
+		"transaction,Burger Queen,20,\n"), nil)
+	for i, valid := range []bool{true, false, false} {
+		msg, err := dec.Decode()
+		assert.NoError(t, err)
+		if valid {
+			assert.NoError(t, Validate(msg), "record %d", i)
+		} else {
+			assert.True(t, errors.Is(Validate(msg), ErrInvalid), "record %d", i)
+		}
+	}
+}
+
+// Test output lines against the output schema of their version.
+func TestSchemaValidateOutput(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(ValidateOutput(V1, `{"account": {}, "violations": []}`))
+	assert.NoError(ValidateOutput(V1, `{"account-id": "a1", "account": {"active-card": true, "available-limit": 80}, "violations": ["insufficient-limit"], "decision": "declined", "risk-score": 20}`))
+	assert.Error(ValidateOutput(V1, `{"account": {}, "violations": ["unknown"]}`))
+	assert.Error(ValidateOutput(V1, `{"version": 2, "account": {}, "violations": []}`))
+
+	assert.NoError(ValidateOutput(V2, `{"version": 2, "violations": [], "error": "unknown operation"}`))
+	assert.NoError(ValidateOutput(V2, `{"version": 2, "operation": "transaction", "violations": [], "transaction-id": "t1", "decision": "approved", "risk-score": 0, "replayed": true}`))
+	assert.Error(ValidateOutput(V2, `{"version": 2, "operation": "transaction", "violations": []}`))
+	assert.Error(ValidateOutput(V2, `{"account": {}, "violations": []}`))
+}
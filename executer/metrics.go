@@ -0,0 +1,52 @@
+// ********************************************************************
+// * metrics.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Does not count replayed decisions again, JR           *
+// *                                                                  *
+// * Updates the prometheus metrics with every operation run by the   *
+// * executer.                                                        *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetMetrics(metrics.New())                                      *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/metrics"
+	"time"
+)
+
+// SetMetrics - Enables the metrics of the operations, nil disables them.
+func (exe *Executer) SetMetrics(m *metrics.Metrics) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.metrics = m
+}
+
+// currentMetrics - Returns the metrics in use, nil if disabled.
+func (exe *Executer) currentMetrics() *metrics.Metrics {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	return exe.metrics
+}
+
+// observe - Counts the operation, unknown operations have no type,
+// since $start. Replayed transactions are counted as operations, but not,
+// their decision and violations again.
+func (exe *Executer) observe(operation string, start time.Time, d Decision) {
+	m := exe.currentMetrics()
+	if m == nil {
+		return
+	}
+
+	if operation == "" {
+		operation = "unknown"
+	}
+	if d.Replayed {
+		m.Operation(operation, time.Since(start), "", nil)
+		return
+	}
+	m.Operation(operation, time.Since(start), d.Decision, d.Violations)
+}
@@ -0,0 +1,129 @@
+// ********************************************************************
+// * metrics_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds accounts by card state test, JR                  *
+// * 2026-10-19 Adds replayed transactions test, JR                   *
+// *                                                                  *
+// * This file contains all unit testing related with the metrics     *
+// * updated by the executer, scraped with httptest.                  *
+// *                                                                  *
+// * Usage: go test -v -run Metrics ./executer                        *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"authorizer/metrics"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tscrape - Returns the metrics exposition served by the handler.
+func tscrape(t *testing.T, m *metrics.Metrics) string {
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+// Test the metrics of executed operations.
+func TestMetricsExec(t *testing.T) {
+	m := metrics.New()
+	exe := Init()
+	exe.SetMetrics(m)
+	for _, op := range []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"merchant": "Burger King", "amount": 30, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"account-update": {"denied-merchants": ["Habbib's"]}}`,
+		`{"unknown": {}}`,
+	} {
+		exe.Exec(op)
+	}
+
+	body := tscrape(t, m)
+	assert := assert.New(t)
+	assert.Contains(body, `authorizer_operations_total{operation="account"} 2`)
+	assert.Contains(body, `authorizer_operations_total{operation="account-update"} 1`)
+	assert.Contains(body, `authorizer_operations_total{operation="transaction"} 2`)
+	assert.Contains(body, `authorizer_operations_total{operation="unknown"} 1`)
+	assert.Contains(body, `authorizer_decisions_total{decision="approved"} 1`)
+	assert.Contains(body, `authorizer_decisions_total{decision="declined"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="2",violation="account-already-initialized"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="6",violation="blocked-merchant"} 1`)
+	assert.Contains(body, `authorizer_transaction_amount_sum 50`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 1`)
+}
+
+// Test replayed transactions are counted as operations, but their decision,
+// and violations only once.
+func TestMetricsReplayed(t *testing.T) {
+	m := metrics.New()
+	exe := Init()
+	exe.SetMetrics(m)
+	for _, op := range []string{
+		`{"account": {"active-card": true, "available-limit": 100}}`,
+		`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"id": "t2", "merchant": "Burger King", "amount": 500, "time": "2019-02-13T10:01:00.000Z"}}`,
+		`{"transaction": {"id": "t1", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+		`{"transaction": {"id": "t2", "merchant": "Burger King", "amount": 500, "time": "2019-02-13T10:01:00.000Z"}}`,
+	} {
+		exe.Exec(op)
+	}
+
+	body := tscrape(t, m)
+	assert := assert.New(t)
+	assert.Contains(body, `authorizer_operations_total{operation="transaction"} 4`)
+	assert.Contains(body, `authorizer_decisions_total{decision="approved"} 1`)
+	assert.Contains(body, `authorizer_decisions_total{decision="declined"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="3",violation="insufficient-limit"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="6",violation="blocked-merchant"} 1`)
+	assert.Contains(body, `authorizer_transaction_amount_count 2`)
+}
+
+// Test accounts are counted by their card state, on creation and on every,
+// card state change.
+func TestMetricsAccountsCard(t *testing.T) {
+	m := metrics.New()
+	exe := Init()
+	exe.SetMetrics(m)
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	exe.CreateAccount(ctx, "a", AccountSettings{Active: true, Limit: 100, Card: account.CardBlocked})
+	exe.CreateAccount(ctx, "b", AccountSettings{Active: true, Limit: 100, Card: account.CardLost})
+	exe.CreateAccount(ctx, "c", AccountSettings{Active: false, Limit: 100})
+	body := tscrape(t, m)
+	assert.Contains(body, `authorizer_accounts{active="false"} 3`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 0`)
+
+	exe.UpdateAccount(ctx, "a", account.Update{CardState: account.CardActive})
+	exe.UpdateAccount(ctx, "c", account.Update{CardState: account.CardActive})
+	body = tscrape(t, m)
+	assert.Contains(body, `authorizer_accounts{active="false"} 1`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 2`)
+
+	// Transitions not allowed, or to the same state, move nothing.
+	exe.UpdateAccount(ctx, "b", account.Update{CardState: account.CardActive})
+	exe.UpdateAccount(ctx, "a", account.Update{CardState: account.CardActive})
+	exe.UpdateAccount(ctx, "c", account.Update{CardState: account.CardStolen})
+	body = tscrape(t, m)
+	assert.Contains(body, `authorizer_accounts{active="false"} 2`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 1`)
+}
@@ -0,0 +1,88 @@
+// ********************************************************************
+// * protocol.go                                                      *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Protocol version of the executer outputs and validation of the   *
+// * operations against the schema of their version. By default each  *
+// * output is in the version of its operation, v1 when not set, a    *
+// * stream may have all its outputs in a single version instead.     *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetOutputVersion(message.V2)                                   *
+// * e.SetValidation(true)                                            *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"fmt"
+)
+
+// SetOutputVersion - Changes the protocol version of the outputs, 0 outputs,
+// each operation in its own version.
+func (exe *Executer) SetOutputVersion(version int) error {
+	if version != 0 && !message.SupportedVersion(version) {
+		return fmt.Errorf("%w %d", message.ErrVersion, version)
+	}
+
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.outputVersion = version
+	return nil
+}
+
+// SetValidation - Enables or disables the validation of the operations,
+// against their version schema, operations not valid are ignored.
+func (exe *Executer) SetValidation(enabled bool) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.validation = enabled
+}
+
+// validate - Returns the error of the operation message against its schema,
+// nil if the validation is disabled.
+func (exe *Executer) validate(msg *message.Message) error {
+	exe.mu.RLock()
+	validation := exe.validation
+	exe.mu.RUnlock()
+	if !validation {
+		return nil
+	}
+
+	return message.Validate(msg)
+}
+
+// version - Returns the protocol version of the output of the operation,
+// message, the one of the executer if set.
+func (exe *Executer) version(msg *message.Message) int {
+	exe.mu.RLock()
+	version := exe.outputVersion
+	exe.mu.RUnlock()
+	if version == 0 {
+		version = msg.Version
+	}
+	if version == 0 {
+		return message.V1
+	}
+
+	return version
+}
+
+// encodeV2 - Adds to the output message the v2 fields of the operation,
+// decision, transactions always output their id, decision and score,
+// ignored operations the error.
+func encodeV2(msg *message.Message, operation string, d Decision, ignored error) {
+	msg.Version = message.V2
+	msg.Operation = operation
+	if operation == message.Transaction {
+		msg.TransactionID = d.TransactionID
+		msg.AuthorizationCode = d.AuthorizationCode
+		msg.SetDecision(d.Decision, d.Score)
+		msg.Replayed = d.Replayed
+	}
+	if ignored != nil {
+		msg.Error = ignored.Error()
+	}
+}
@@ -0,0 +1,124 @@
+// ********************************************************************
+// * protocol_test.go                                                 *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the outputs     *
+// * protocol versions and the operations validation.                 *
+// *                                                                  *
+// * Usage: go test -v -run Protocol ./executer                       *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test the e2e outputs, in the original format, match the v1 schema.
+func TestProtocolV1(t *testing.T) {
+	ins, err := filepath.Glob(filepath.Join("..", "test", "*", "in"))
+	if err != nil || len(ins) == 0 {
+		t.Fatal("No e2e scenarios found.", err)
+	}
+	for _, in := range ins {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exe := Init()
+		exe.SetDecisions(true)
+		exe.SetTransactionIDs(true)
+		for _, op := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if op == "" {
+				continue
+			}
+			out := exe.Exec(op)
+			assert.NoError(t, message.ValidateOutput(message.V1, out), "%s: %s", in, out)
+		}
+	}
+}
+
+// Test the v2 outputs of every operation.
+func TestProtocolV2(t *testing.T) {
+	exe := Init()
+	assert := assert.New(t)
+	assert.NoError(exe.SetOutputVersion(message.V2))
+	for _, tt := range []struct {
+		op  string
+		out string
+	}{
+		{
+			`{"account-id": "a1", "transaction": {"id": "t0", "merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"version": 2, "operation": "transaction", "account-id": "a1", "violations": ["account-not-initialized"], "transaction-id": "t0", "decision": "declined", "risk-score": 0}`,
+		},
+		{
+			`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+			`{"version": 2, "operation": "account", "account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		},
+		{
+			`{"account-id": "a1", "account-update": {"denied-merchants": ["Habbib's"]}}`,
+			`{"version": 2, "operation": "account-update", "account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		},
+		{
+			`{"account-id": "a1", "transaction": {"id": "t1", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"version": 2, "operation": "transaction", "account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": ["account-blocked-merchant"], "transaction-id": "t1", "decision": "declined", "risk-score": 100}`,
+		},
+		{
+			`{"account-id": "a1", "transaction": {"id": "t1", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+			`{"version": 2, "operation": "transaction", "account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": ["account-blocked-merchant"], "transaction-id": "t1", "decision": "declined", "risk-score": 100, "replayed": true}`,
+		},
+		{
+			`{"account-id": "a1", "unknown": {}}`,
+			`{"version": 2, "account-id": "a1", "account": {"active-card": true, "available-limit": 100}, "violations": [], "error": "unknown operation"}`,
+		},
+	} {
+		out := exe.Exec(tt.op)
+		assert.Equal(tt.out, out)
+		assert.NoError(message.ValidateOutput(message.V2, out))
+	}
+
+	assert.True(errors.Is(exe.SetOutputVersion(3), message.ErrVersion))
+}
+
+// Test each output is in the version of its operation by default.
+func TestProtocolNegotiation(t *testing.T) {
+	exe := Init()
+	assert := assert.New(t)
+	assert.Equal(`{"version": 2, "operation": "account", "account": {"active-card": true, "available-limit": 100}, "violations": []}`,
+		exe.Exec(`{"version": 2, "account": {"active-card": true, "available-limit": 100}}`))
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+		exe.Exec(`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`))
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+		exe.Exec(`{"version": 3, "account-update": {}}`))
+
+	// A stream version is kept for every operation.
+	exe.SetOutputVersion(message.V1)
+	assert.Equal(`{"account": {"active-card": true, "available-limit": 80}, "violations": []}`,
+		exe.Exec(`{"version": 2, "account-update": {}}`))
+}
+
+// Test operations not valid are ignored, keeping their account-id.
+func TestProtocolValidation(t *testing.T) {
+	exe := Init()
+	assert := assert.New(t)
+	exe.Exec(`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`)
+	invalid := `{"version": 2, "account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": 20}}`
+
+	// Disabled by default.
+	assert.Contains(exe.Exec(invalid), `"available-limit": 80`)
+
+	exe.SetValidation(true)
+	out, err := exe.ExecContext(context.Background(), invalid)
+	assert.True(errors.Is(err, message.ErrInvalid))
+	assert.Equal(`{"version": 2, "account-id": "a1", "account": {"active-card": true, "available-limit": 80}, "violations": [], "error": "invalid operation: /transaction: missing properties: 'time'"}`, out)
+	assert.Equal(`{"account-id": "a1", "account": {"active-card": true, "available-limit": 60}, "violations": []}`,
+		exe.Exec(`{"account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`))
+}
@@ -0,0 +1,162 @@
+// ********************************************************************
+// * shadow.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Makes the report safe for concurrent use, JR          *
+// *                                                                  *
+// * Evaluates transactions with a candidate rules configuration,     *
+// * next to the active one, and reports the transactions where       *
+// * the candidate's decision differs. Only the active rules          *
+// * change the account.                                              *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetCandidate(rules, writer)                                    *
+// * e.Exec(string)                                                   *
+// * e.ShadowSummary()                                                *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Outcome - decision, risk score and violations of a transaction,
+// under a rules configuration.
+type Outcome struct {
+	Decision   string   `json:"decision"`
+	Score      int      `json:"risk-score"`
+	Violations []string `json:"violations"`
+}
+
+// Divergence - represents a transaction with different decisions,
+// for the active and candidate rules.
+type Divergence struct {
+	Line        int                  `json:"line"`
+	Transaction *account.Transaction `json:"transaction"`
+	Active      *Outcome             `json:"active"`
+	Candidate   *Outcome             `json:"candidate"`
+}
+
+// Summary - totals of transactions evaluated with the candidate rules,
+// changes are counted by "active->candidate" decisions.
+type Summary struct {
+	Transactions int            `json:"transactions"`
+	Divergences  int            `json:"divergences"`
+	Changes      map[string]int `json:"changes"`
+}
+
+// shadow - Holds the candidate rules and where divergences are reported,
+// the lock guards the summary and the report.
+type shadow struct {
+	mu      sync.Mutex
+	rules   *account.Rules
+	report  io.Writer
+	summary *Summary
+}
+
+// SetCandidate - Enables the shadow evaluation with the candidate rules,
+// each divergence is written to the report as a json line, nil disables it.
+func (exe *Executer) SetCandidate(r *account.Rules, report io.Writer) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	if r == nil {
+		exe.shadow = nil
+		return
+	}
+
+	exe.shadow = &shadow{
+		rules:  r,
+		report: report,
+		summary: &Summary{
+			Changes: map[string]int{},
+		},
+	}
+}
+
+// ShadowSummary - Returns the totals of the shadow evaluation,
+// nil if there is no candidate rules, to be read once operations end.
+func (exe *Executer) ShadowSummary() *Summary {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	if exe.shadow == nil {
+		return nil
+	}
+
+	return exe.shadow.summary
+}
+
+// WriteShadowSummary - Writes the shadow evaluation totals to the report,
+// as a json line.
+func (exe *Executer) WriteShadowSummary() error {
+	exe.mu.RLock()
+	s := exe.shadow
+	exe.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(map[string]*Summary{"summary": s.summary})
+}
+
+// evaluate - Returns the transaction outcome with the candidate rules,
+// it must be called before the transaction is applied to the account.
+func (s *shadow) evaluate(acn *account.Account, tsn *account.Transaction) *Outcome {
+	return outcome(s.rules, acn.Evaluate(tsn, s.rules))
+}
+
+// compare - Counts the transaction, and reports it if the candidate,
+// decision differs from the active one.
+func (s *shadow) compare(line int, tsn *account.Transaction, active *Outcome, candidate *Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.Transactions++
+	if active.Decision == candidate.Decision {
+		return
+	}
+
+	s.summary.Divergences++
+	s.summary.Changes[active.Decision+"->"+candidate.Decision]++
+	s.write(map[string]*Divergence{
+		"divergence": {
+			Line:        line,
+			Transaction: tsn,
+			Active:      active,
+			Candidate:   candidate,
+		},
+	})
+}
+
+// write - Writes a value to the report as a json line, the lock must,
+// be held.
+func (s *shadow) write(v interface{}) error {
+	if s.report == nil {
+		return nil
+	}
+
+	// Keep "->" readable in the changes keys.
+	enc := json.NewEncoder(s.report)
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(v)
+}
+
+// outcome - Returns the outcome for the violations found with the rules.
+func outcome(r *account.Rules, violations []int) *Outcome {
+	decision, score := r.Decide(violations)
+	names := []string{}
+	for _, v := range violations {
+		names = append(names, account.Violations[v])
+	}
+
+	return &Outcome{
+		Decision:   decision,
+		Score:      score,
+		Violations: names,
+	}
+}
@@ -0,0 +1,75 @@
+// ********************************************************************
+// * shadow_test.go                                                   *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds transaction ids to divergences, JR               *
+// *                                                                  *
+// * This file contains all unit testing related with the shadow      *
+// * evaluation of candidate rules.                                   *
+// *                                                                  *
+// * Usage: go test -v ./executer                                     *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/account"
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// Test input evaluated with the candidate rules.
+var tshadow = []string{
+	`{"account": {"active-card": true, "available-limit": 100}}`,
+	`{"transaction": {"merchant": "Burger Queen", "amount": 50, "time": "2019-02-13T10:00:00.000Z"}}`,
+	`{"transaction": {"id": "t2", "merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+	`{"transaction": {"merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:05:00.000Z"}}`,
+}
+
+// Test candidate rules divergences, and account driven by active rules.
+func TestShadowDivergences(t *testing.T) {
+	candidate := account.DefaultRules()
+	candidate.MaxAmount = 60
+	candidate.Actions[6] = account.Flag
+	report := &bytes.Buffer{}
+
+	exe := Init()
+	exe.SetIDGenerator(SeededIDs(1))
+	exe.SetCandidate(candidate, report)
+	out := []string{}
+	for _, op := range tshadow {
+		out = append(out, exe.Exec(op))
+	}
+	exe.WriteShadowSummary()
+
+	assert := assert.New(t)
+	assert.Equal(
+		`{"account": {"active-card": true, "available-limit": 30}, "violations": []}`,
+		out[2],
+		"Expected account changed only by active rules.",
+	)
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	assert.Equal(
+		[]string{
+			`{"divergence":{"line":3,"transaction":{"id":"t2","merchant":"Habbib's","amount":20,"time":"2019-02-13T10:01:00.000Z"},"active":{"decision":"approved","risk-score":0,"violations":[]},"candidate":{"decision":"declined","risk-score":40,"violations":["high-amount-small-interval"]}}}`,
+			fmt.Sprintf(`{"divergence":{"line":4,"transaction":{"id":"%s","merchant":"Burger King","amount":20,"time":"2019-02-13T10:05:00.000Z"},"active":{"decision":"declined","risk-score":100,"violations":["blocked-merchant"]},"candidate":{"decision":"approved-with-flags","risk-score":100,"violations":["blocked-merchant"]}}}`, SeededIDs(1).TransactionID("", 4)),
+			`{"summary":{"transactions":3,"divergences":2,"changes":{"approved->declined":1,"declined->approved-with-flags":1}}}`,
+		},
+		lines,
+		"Expected divergences and summary reported.",
+	)
+	assert.Equal(2, exe.ShadowSummary().Divergences, "Expected 2 divergences.")
+}
+
+// Test shadow evaluation disabled.
+func TestShadowDisabled(t *testing.T) {
+	exe := Init()
+	exe.SetCandidate(nil, nil)
+	assert := assert.New(t)
+	assert.Nil(exe.ShadowSummary(), "Expected no shadow summary.")
+	assert.Nil(exe.WriteShadowSummary(), "Expected no error.")
+}
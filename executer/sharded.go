@@ -0,0 +1,161 @@
+// ********************************************************************
+// * sharded.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Returns audit log errors, JR                          *
+// * 2026-10-19 Adds Stream, over the message codecs, JR              *
+// * 2026-10-19 Numbers operations by their input position, JR        *
+// *                                                                  *
+// * Executes json operation lines concurrently, operations are       *
+// * sharded by account-id across workers, each one with its own      *
+// * executer, so every account keeps its operations order, and       *
+// * the output lines are written in the original input order.        *
+// *                                                                  *
+// * Usage:                                                           *
+// * s := executer.InitSharded(workers, executer.Init)                *
+// * s.Run(reader, writer)                                            *
+// * s.Stream(decoder, encoder)                                       *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// Number of operations queued by each worker.
+const shardQueue = 64
+
+// Sharded - Holds the executers, one by worker.
+type Sharded struct {
+	executers []*Executer
+}
+
+// job - an operation message and its position in the input, with the,
+// time it was decoded since, the raw input and decode error if any, and,
+// its output message.
+type job struct {
+	seq    int
+	start  time.Time
+	msg    *message.Message
+	input  string
+	err    error
+	output *message.Message
+}
+
+// InitSharded - Returns a new Sharded with $workers executers, created by,
+// the $init function so all of them share the same settings.
+func InitSharded(workers int, init func() *Executer) *Sharded {
+	if workers < 1 {
+		workers = 1
+	}
+
+	sharded := &Sharded{}
+	for i := 0; i < workers; i++ {
+		sharded.executers = append(sharded.executers, init())
+	}
+
+	return sharded
+}
+
+// Run - Reads operations line by line, while not empty line, executes,
+// them concurrently and writes the json output lines in the input order.
+// Returns the first write error, or else the first audit log error.
+func (s *Sharded) Run(in io.Reader, out io.Writer) error {
+	return s.Stream(message.NewNDJSONDecoder(in), message.NewNDJSONEncoder(out))
+}
+
+// Stream - Executes the operations decoded concurrently, and encodes the,
+// outputs in the input order, records not decoded are run as unknown,
+// operations. Returns the first encoder error, or else the first decoder,
+// error ending the stream, or else the first audit log error.
+func (s *Sharded) Stream(dec message.Decoder, enc message.Encoder) error {
+	queues := make([]chan job, len(s.executers))
+	results := make(chan job, len(s.executers)*shardQueue)
+	var workers sync.WaitGroup
+	var auditMu sync.Mutex
+	var auditErr error
+	for i, exe := range s.executers {
+		queues[i] = make(chan job, shardQueue)
+		workers.Add(1)
+		go func(exe *Executer, queue chan job) {
+			defer workers.Done()
+			for j := range queue {
+				// Numbered by the input position, as without workers.
+				err := exe.exec(context.Background(), j.start, j.seq+1, j.msg, j.input, j.err, func(out *message.Message) error {
+					j.output = out
+					return nil
+				})
+				if errors.Is(err, ErrAudit) {
+					auditMu.Lock()
+					if auditErr == nil {
+						auditErr = err
+					}
+					auditMu.Unlock()
+				}
+				results <- j
+			}
+		}(exe, queues[i])
+	}
+
+	// Reader, routes each operation to the worker of its account.
+	var decodeErr error
+	go func() {
+		for seq := 0; ; seq++ {
+			start := time.Now()
+			msg, input, err := decode(dec)
+			if msg == nil {
+				if !errors.Is(err, io.EOF) {
+					decodeErr = err
+				}
+				break
+			}
+			queues[s.shard(msg.AccountID)] <- job{seq: seq, start: start, msg: msg, input: input, err: err}
+		}
+		for _, queue := range queues {
+			close(queue)
+		}
+		workers.Wait()
+		close(results)
+	}()
+
+	// Writer, keeps the outputs until the previous ones are written.
+	pending := map[int]*message.Message{}
+	next := 0
+	var err error
+	for r := range results {
+		pending[r.seq] = r.output
+		for output, ok := pending[next]; ok; output, ok = pending[next] {
+			delete(pending, next)
+			next++
+			// Keep consuming after an error, to let the workers finish.
+			if err == nil {
+				err = enc.Encode(output)
+			}
+		}
+	}
+	if err == nil {
+		err = decodeErr
+	}
+	if err == nil {
+		err = auditErr
+	}
+
+	return err
+}
+
+// shard - Returns the worker index for the account-id, operations not,
+// decoded go with the operations without account-id, as the executer,
+// handles them.
+func (s *Sharded) shard(id string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+
+	return int(hash.Sum32() % uint32(len(s.executers)))
+}
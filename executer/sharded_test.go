@@ -0,0 +1,135 @@
+// ********************************************************************
+// * sharded_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Checks seeded ids do not depend on the workers, JR    *
+// *                                                                  *
+// * This file contains all unit testing related with the sharded     *
+// * concurrent execution, to run with the race detector.             *
+// *                                                                  *
+// * Usage: go test -v -race -run Sharded ./executer                  *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"authorizer/generator"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// sequential - Returns the output of the operations executed in order.
+func sequential(ops string) string {
+	exe := Init()
+	out := &bytes.Buffer{}
+	for _, op := range strings.SplitAfter(ops, "\n") {
+		if op == "" {
+			break
+		}
+		fmt.Fprintln(out, exe.Exec(op))
+	}
+
+	return out.String()
+}
+
+// Test sharded output is the same as the sequential one.
+func TestShardedSameOutput(t *testing.T) {
+	cfg := generator.DefaultConfig()
+	cfg.Accounts = 50
+	cfg.Transactions = 5000
+	cfg.Limit = 2000
+	stream := &bytes.Buffer{}
+	if err := generator.Generate(stream, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Operations without account-id, and malformed ones, are sharded too.
+	ops := stream.String() +
+		`{"account": {"active-card": true, "available-limit": 100}}` + "\n" +
+		`{"account-id": "account-1", "transaction": {"amount": "1"}}` + "\n" +
+		`{"transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`
+	expected := sequential(ops)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprintf("workers-%d", workers), func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := InitSharded(workers, Init).Run(strings.NewReader(ops), out)
+			assert := assert.New(t)
+			assert.Nil(err, "Expected no error.")
+			assert.Equal(expected, out.String(), "Expected same output as sequential.")
+		})
+	}
+}
+
+// Test sharded seeded ids are the same as the sequential ones, operations,
+// are numbered by their input position, not by the worker running them.
+func TestShardedSeededIDs(t *testing.T) {
+	cfg := generator.DefaultConfig()
+	cfg.Accounts = 20
+	cfg.Transactions = 2000
+	cfg.Limit = 2000
+	stream := &bytes.Buffer{}
+	if err := generator.Generate(stream, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Transactions without id are assigned a seeded one.
+	ops := stream.String() +
+		`{"account-id": "account-1", "transaction": {"merchant": "Burger Queen", "amount": 1, "time": "2019-02-13T10:00:00.000Z"}}` + "\n" +
+		`{"account-id": "account-2", "transaction": {"merchant": "Habbib's", "amount": 1, "time": "2019-02-13T10:00:00.000Z"}}` + "\n"
+	seeded := func() *Executer {
+		exe := Init()
+		exe.SetIDGenerator(SeededIDs(3))
+		exe.SetTransactionIDs(true)
+		return exe
+	}
+	expected := &bytes.Buffer{}
+	err := seeded().Stream(context.Background(), message.NewNDJSONDecoder(strings.NewReader(ops)), message.NewNDJSONEncoder(expected))
+	assert.Nil(t, err, "Expected no error.")
+	assert.Contains(t, expected.String(), `"transaction-id":`, "Expected generated ids.")
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprintf("workers-%d", workers), func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := InitSharded(workers, seeded).Run(strings.NewReader(ops), out)
+			assert := assert.New(t)
+			assert.Nil(err, "Expected no error.")
+			assert.Equal(expected.String(), out.String(), "Expected same ids as sequential.")
+		})
+	}
+}
+
+// Test sharded execution of an empty input.
+func TestShardedEmpty(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := InitSharded(0, Init).Run(strings.NewReader(""), out)
+	assert := assert.New(t)
+	assert.Nil(err, "Expected no error.")
+	assert.Equal("", out.String(), "Expected no output.")
+}
+
+// failWriter - writer failing after the first write.
+type failWriter struct {
+	writes int
+}
+
+// Write - Fails after the first write.
+func (w *failWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("closed")
+	}
+
+	return len(p), nil
+}
+
+// Test sharded execution returns the output error, after the input ends.
+func TestShardedWriteError(t *testing.T) {
+	ops := strings.Repeat(`{"account": {"active-card": true, "available-limit": 100}}`+"\n", 1000)
+	err := InitSharded(4, Init).Run(strings.NewReader(ops), &failWriter{})
+	assert.NotNil(t, err, "Expected write error.")
+}
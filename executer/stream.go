@@ -0,0 +1,73 @@
+// ********************************************************************
+// * stream.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Numbers operations by their input position, JR        *
+// *                                                                  *
+// * Executes the operations of a stream in any of the messages       *
+// * formats, decoded and encoded by the message package codecs.      *
+// *                                                                  *
+// * Usage:                                                           *
+// * dec, _ := message.NewDecoder(message.CSV, os.Stdin)              *
+// * enc, _ := message.NewEncoder(message.NDJSON, os.Stdout)          *
+// * err := e.Stream(ctx, dec, enc)                                   *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Stream - Executes every operation decoded, in order, and encodes their,
+// outputs, until the input ends. Records not decoded are run as unknown,
+// operations. Returns the first decoder or encoder error, or else the,
+// first audit log error.
+func (exe *Executer) Stream(ctx context.Context, dec message.Decoder, enc message.Encoder) error {
+	var auditErr error
+	for line := 1; ; line++ {
+		start := time.Now()
+		msg, input, err := decode(dec)
+		if errors.Is(err, io.EOF) {
+			return auditErr
+		}
+		if msg == nil {
+			return err
+		}
+
+		var encErr error
+		err = exe.exec(ctx, start, line, msg, input, err, func(out *message.Message) error {
+			encErr = enc.Encode(out)
+			return encErr
+		})
+		if encErr != nil {
+			return encErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if errors.Is(err, ErrAudit) && auditErr == nil {
+			auditErr = err
+		}
+	}
+}
+
+// decode - Returns the next message of the decoder, with the raw input,
+// and the error of the records not decoded. The message is nil if the,
+// stream ends.
+func decode(dec message.Decoder) (*message.Message, string, error) {
+	msg, err := dec.Decode()
+	var decodeErr *message.DecodeError
+	if errors.As(err, &decodeErr) {
+		return msg, decodeErr.Input, decodeErr.Err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return msg, "", nil
+}
@@ -0,0 +1,126 @@
+// ********************************************************************
+// * stream_test.go                                                   *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the execution   *
+// * of streams in every messages format.                             *
+// *                                                                  *
+// * Usage: go test -v -run Stream ./executer                         *
+// ********************************************************************
+
+package executer
+
+import (
+	"authorizer/executer/message"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Test operations, as json lines, with a malformed one.
+var tstream = []string{
+	`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100, "denied-merchants": ["Habbib's"]}}`,
+	`{"account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	`{"account-id": "a1", "transaction": {"merchant": "Habbib's", "amount": 20, "time": "2019-02-13T10:01:00.000Z"}}`,
+	`{"account-id": "a1", "account-update": {"denied-merchants": ["Burger Queen"]}}`,
+	`{"account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": 30, "time": "2019-02-13T10:02:00.000Z"}}`,
+	`{"account-id": "a1", "transaction": {"merchant": "Burger Queen", "amount": "ten"}}`,
+}
+
+// Test same operations as csv.
+const tstreamCSV = `type,account-id,active-card,available-limit,denied-merchants,merchant,amount,time
+account,a1,true,100,Habbib's,,,
+transaction,a1,,,,Burger Queen,20,2019-02-13T10:00:00.000Z
+transaction,a1,,,,Habbib's,20,2019-02-13T10:01:00.000Z
+account-update,a1,,,Burger Queen,,,
+transaction,a1,,,,Burger Queen,30,2019-02-13T10:02:00.000Z
+transaction,a1,,,,Burger Queen,ten,
+`
+
+// texpected - Returns the json lines output of the operations executed.
+func texpected(ops []string) string {
+	exe := Init()
+	out := &bytes.Buffer{}
+	for _, op := range ops {
+		out.WriteString(exe.Exec(op) + "\n")
+	}
+
+	return out.String()
+}
+
+// Test a csv stream has the same output as the json lines one.
+func TestStreamCSV(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := Init().Stream(context.Background(),
+		message.NewCSVDecoder(strings.NewReader(tstreamCSV), nil), message.NewNDJSONEncoder(out))
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.Equal(texpected(tstream), out.String())
+
+	// And sharded.
+	out.Reset()
+	err = InitSharded(4, Init).Stream(message.NewCSVDecoder(strings.NewReader(tstreamCSV), nil), message.NewNDJSONEncoder(out))
+	assert.NoError(err)
+	assert.Equal(texpected(tstream), out.String())
+}
+
+// Test a msgpack stream has the same outputs as the json lines one.
+func TestStreamMsgPack(t *testing.T) {
+	in := &bytes.Buffer{}
+	enc := message.NewMsgPackEncoder(in)
+	for _, op := range tstream[:5] {
+		msg, _ := message.Decode(op)
+		enc.Encode(msg)
+	}
+	out := &bytes.Buffer{}
+	err := Init().Stream(context.Background(), message.NewMsgPackDecoder(in), message.NewMsgPackEncoder(out))
+	assert := assert.New(t)
+	assert.NoError(err)
+
+	// Outputs are decoded with their output fields.
+	dec := msgpack.NewDecoder(out)
+	dec.SetCustomStructTag("json")
+	for _, line := range strings.Split(strings.TrimSpace(texpected(tstream[:5])), "\n") {
+		msg := &message.Message{}
+		assert.NoError(dec.Decode(msg))
+		assert.Equal(line, message.Encode(msg))
+	}
+}
+
+// failEncoder - encoder failing after the first message.
+type failEncoder struct {
+	encoded int
+}
+
+// Encode - Fails after the first message.
+func (enc *failEncoder) Encode(*message.Message) error {
+	enc.encoded++
+	if enc.encoded > 1 {
+		return errors.New("closed")
+	}
+
+	return nil
+}
+
+// Test the stream stops with the encoder error.
+func TestStreamEncodeError(t *testing.T) {
+	enc := &failEncoder{}
+	err := Init().Stream(context.Background(), message.NewNDJSONDecoder(strings.NewReader(strings.Join(tstream, "\n"))), enc)
+	assert := assert.New(t)
+	assert.EqualError(err, "closed")
+	assert.Equal(2, enc.encoded, "Expected the stream stopped.")
+}
+
+// Test the stream stops when the context is canceled.
+func TestStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Init().Stream(ctx, message.NewNDJSONDecoder(strings.NewReader(tstream[0])), message.NewNDJSONEncoder(io.Discard))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,68 @@
+// ********************************************************************
+// * tracing.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Starts the opentelemetry spans of every operation run by the     *
+// * executer, its decoding and encoding, the account checks are      *
+// * children spans of the operation.                                 *
+// *                                                                  *
+// * Usage:                                                           *
+// * e.SetTracerProvider(tp)                                          *
+// ********************************************************************
+
+package executer
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Name of the executer spans tracer.
+const tracerName = "authorizer/executer"
+
+// SetTracerProvider - Enables the spans of the operations, from the,
+// provider, nil disables them.
+func (exe *Executer) SetTracerProvider(tp trace.TracerProvider) {
+	exe.mu.Lock()
+	defer exe.mu.Unlock()
+	exe.tracer = nil
+	if tp != nil {
+		exe.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// currentTracer - Returns the tracer in use, a no-op one if disabled.
+func (exe *Executer) currentTracer() trace.Tracer {
+	exe.mu.RLock()
+	defer exe.mu.RUnlock()
+	if exe.tracer == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	return exe.tracer
+}
+
+// attributes - Returns the span attributes of the operation decision.
+func attributes(operation string, d Decision) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("account.id", string(d.AccountID)),
+		attribute.String("operation", operation),
+		attribute.IntSlice("violation.codes", d.Violations),
+	}
+	if d.Decision != "" {
+		attrs = append(attrs, attribute.String("decision", d.Decision))
+	}
+
+	return attrs
+}
+
+// fail - Records the error in the span, if any.
+func fail(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,96 @@
+// ********************************************************************
+// * tracing_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the spans of    *
+// * the operations run by the executer, recorded in memory.          *
+// *                                                                  *
+// * Usage: go test -v -run Spans ./executer                          *
+// ********************************************************************
+
+package executer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+// tspans - Returns the spans ended while executing the operations.
+func tspans(ops ...string) []sdktrace.ReadOnlySpan {
+	recorder := tracetest.NewSpanRecorder()
+	exe := Init()
+	exe.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	for _, op := range ops {
+		exe.Exec(op)
+	}
+
+	return recorder.Ended()
+}
+
+// tattribute - Returns the value of the span attribute.
+func tattribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+// Test the exec span, with the decode, checks and encode children spans.
+func TestSpansExec(t *testing.T) {
+	spans := tspans(
+		`{"account-id": "a1", "account": {"active-card": true, "available-limit": 100}}`,
+		`{"account-id": "a1", "transaction": {"merchant": "Burger King", "amount": 20, "time": "2019-02-13T10:00:00.000Z"}}`,
+	)
+	assert := assert.New(t)
+
+	names := []string{}
+	var exec sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		names = append(names, span.Name())
+		if span.Name() == "exec" {
+			exec = span
+		}
+	}
+	assert.Equal([]string{"decode", "encode", "exec"}, names[:3], "Expected the account operation spans.")
+	assert.Equal("decode", names[3])
+	assert.Contains(names, "check blocked-merchant")
+	assert.Equal([]string{"encode", "exec"}, names[len(names)-2:])
+
+	// The last exec span is the transaction one.
+	assert.Equal("a1", tattribute(exec, "account.id").AsString())
+	assert.Equal("transaction", tattribute(exec, "operation").AsString())
+	assert.Equal([]int64{6}, tattribute(exec, "violation.codes").AsInt64Slice())
+	assert.Equal("declined", tattribute(exec, "decision").AsString())
+	for _, span := range spans[3 : len(spans)-1] {
+		assert.Equal(exec.SpanContext().SpanID(), span.Parent().SpanID(), "Expected %s child of exec.", span.Name())
+	}
+}
+
+// Test malformed operations are recorded as errors.
+func TestSpansMalformed(t *testing.T) {
+	spans := tspans(`{"account": {"active-card": tru`)
+	assert := assert.New(t)
+	assert.Len(spans, 3, "Expected decode, encode and exec spans.")
+	assert.Equal("decode", spans[0].Name())
+	assert.Equal(codes.Error, spans[0].Status().Code)
+	assert.Equal("exec", spans[2].Name())
+	assert.Equal(codes.Error, spans[2].Status().Code)
+}
+
+// Test no spans without tracer provider.
+func TestSpansDisabled(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	exe := Init()
+	exe.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	exe.SetTracerProvider(nil)
+	exe.Exec(`{"account": {"active-card": true, "available-limit": 100}}`)
+	assert.Empty(t, recorder.Ended())
+}
@@ -0,0 +1,64 @@
+// ********************************************************************
+// * gen.go                                                           *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * The "gen" subcommand, writes to stdout a synthetic stream of     *
+// * json operation lines, to be used as the authorizer input.        *
+// *                                                                  *
+// * Usage:                                                           *
+// * $ authorizer gen -accounts 10 -transactions 1000 -seed 7 > $FILE *
+// ********************************************************************
+
+package main
+
+import (
+	"authorizer/generator"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// gen - Parses the gen subcommand flags and writes the stream,
+// returns the exit code.
+func gen(args []string, out io.Writer) int {
+	cfg := generator.DefaultConfig()
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.IntVar(&cfg.Accounts, "accounts", cfg.Accounts,
+		"Number of accounts, more than one adds an account-id to operations.")
+	flags.IntVar(&cfg.Limit, "limit", cfg.Limit,
+		"Initial available limit of each account.")
+	flags.IntVar(&cfg.Transactions, "transactions", cfg.Transactions,
+		"Number of transactions, for all the accounts.")
+	flags.IntVar(&cfg.Merchants, "merchants", cfg.Merchants,
+		"Number of distinct merchants.")
+	flags.Float64Var(&cfg.MerchantSkew, "merchant-skew", cfg.MerchantSkew,
+		"Skew of the merchants popularity (zipf s > 1), 0 is uniform.")
+	flags.StringVar(&cfg.Amounts, "amounts", cfg.Amounts,
+		"Amounts distribution, uniform or exponential.")
+	flags.IntVar(&cfg.MinAmount, "min-amount", cfg.MinAmount,
+		"Min transaction amount.")
+	flags.IntVar(&cfg.MaxAmount, "max-amount", cfg.MaxAmount,
+		"Max transaction amount.")
+	flags.DurationVar(&cfg.Gap, "gap", cfg.Gap,
+		"Mean time between transactions.")
+	flags.Float64Var(&cfg.BurstRate, "burst-rate", cfg.BurstRate,
+		"Probability of a transaction starting a burst (high frequency).")
+	flags.IntVar(&cfg.BurstSize, "burst-size", cfg.BurstSize,
+		"Number of transactions within seconds in a burst.")
+	flags.Float64Var(&cfg.DoubledRate, "doubled-rate", cfg.DoubledRate,
+		"Probability of repeating the account's previous transaction.")
+	flags.Int64Var(&cfg.Seed, "seed", cfg.Seed,
+		"Seed of the random generator, same seed same stream.")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := generator.Generate(out, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,235 @@
+// ********************************************************************
+// * generator.go                                                     *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Package responsible of generate synthetic json operation lines,  *
+// * accounts and transactions streams, in the same format the        *
+// * authorizer reads, to load test and demo the application.         *
+// *                                                                  *
+// * Usage:                                                           *
+// * cfg := generator.DefaultConfig()                                 *
+// * generator.Generate(writer, cfg)                                  *
+// ********************************************************************
+
+package generator
+
+import (
+	"authorizer/account"
+	"authorizer/executer/message"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Amount distributions.
+const (
+	Uniform     = "uniform"
+	Exponential = "exponential"
+)
+
+// Time format used by the transactions.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Config - Holds the shape of the generated stream.
+type Config struct {
+	// Number of accounts, more than one adds an account-id to operations.
+	Accounts int
+	// Initial available limit of each account.
+	Limit int
+	// Number of transactions, for all the accounts.
+	Transactions int
+	// Number of distinct merchants.
+	Merchants int
+	// Skew of the merchants popularity (zipf s > 1), 0 is uniform.
+	MerchantSkew float64
+	// Amount distribution, uniform or exponential.
+	Amounts string
+	// Min and max amounts, the exponential distribution has $MinAmount,
+	// plus a mean of a quarter of the range.
+	MinAmount int
+	MaxAmount int
+	// Mean time between transactions.
+	Gap time.Duration
+	// Probability of a transaction starting a burst of $BurstSize,
+	// transactions within seconds, to get high frequency violations.
+	BurstRate float64
+	BurstSize int
+	// Probability of repeating the previous transaction of the account,
+	// to get doubled violations.
+	DoubledRate float64
+	// Time of the first transaction.
+	Start time.Time
+	// Seed of the random generator, same seed same stream.
+	Seed int64
+}
+
+// DefaultConfig - Returns the config of a small single account stream.
+func DefaultConfig() *Config {
+	return &Config{
+		Accounts:     1,
+		Limit:        1000,
+		Transactions: 100,
+		Merchants:    20,
+		MerchantSkew: 1.5,
+		Amounts:      Exponential,
+		MinAmount:    1,
+		MaxAmount:    200,
+		Gap:          5 * time.Minute,
+		BurstRate:    0.05,
+		BurstSize:    4,
+		DoubledRate:  0.05,
+		Start:        time.Date(2019, 2, 13, 10, 0, 0, 0, time.UTC),
+		Seed:         1,
+	}
+}
+
+// operation - json operation line, the input of the authorizer.
+type operation struct {
+	AccountID   string                  `json:"account-id,omitempty"`
+	Account     *message.AccountMessage `json:"account,omitempty"`
+	Transaction *account.Transaction    `json:"transaction,omitempty"`
+}
+
+// generator - Holds the state while the stream is generated.
+type generator struct {
+	cfg      *Config
+	rnd      *rand.Rand
+	zipf     *rand.Zipf
+	now      time.Time
+	previous map[string]*account.Transaction
+	out      *json.Encoder
+}
+
+// Generate - Writes the json operation lines, first the accounts creation,
+// then the transactions, returns an error if the config is not valid or
+// the stream can't be written.
+func Generate(w io.Writer, cfg *Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	gen := &generator{
+		cfg:      cfg,
+		rnd:      rand.New(rand.NewSource(cfg.Seed)),
+		now:      cfg.Start,
+		previous: map[string]*account.Transaction{},
+		out:      json.NewEncoder(buf),
+	}
+	gen.out.SetEscapeHTML(false)
+	if cfg.MerchantSkew > 1 {
+		gen.zipf = rand.NewZipf(gen.rnd, cfg.MerchantSkew, 1, uint64(cfg.Merchants-1))
+	}
+
+	for i := 0; i < cfg.Accounts; i++ {
+		err := gen.out.Encode(&operation{
+			AccountID: gen.accountID(i),
+			Account: &message.AccountMessage{
+				Active: true,
+				Limit:  cfg.Limit,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for count := 0; count < cfg.Transactions; {
+		id := gen.accountID(gen.rnd.Intn(cfg.Accounts))
+		size := 1
+		if gen.rnd.Float64() < cfg.BurstRate {
+			size = cfg.BurstSize
+		}
+		// Bursts are cut to the transactions left.
+		for j := 0; j < size && count < cfg.Transactions; j++ {
+			if err := gen.out.Encode(gen.transaction(id, j > 0)); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	return buf.Flush()
+}
+
+// validate - Returns an error if the config can't generate a stream.
+func (cfg *Config) validate() error {
+	switch {
+	case cfg.Accounts < 1:
+		return fmt.Errorf("accounts must be at least 1, got %d", cfg.Accounts)
+	case cfg.Merchants < 1:
+		return fmt.Errorf("merchants must be at least 1, got %d", cfg.Merchants)
+	case cfg.MinAmount < 0 || cfg.MaxAmount < cfg.MinAmount:
+		return fmt.Errorf("invalid amounts range [%d, %d]", cfg.MinAmount, cfg.MaxAmount)
+	case cfg.Amounts != Uniform && cfg.Amounts != Exponential:
+		return fmt.Errorf("unknown amounts distribution %q", cfg.Amounts)
+	case cfg.Gap < 0:
+		return fmt.Errorf("gap must not be negative, got %v", cfg.Gap)
+	case cfg.BurstSize < 1:
+		return fmt.Errorf("burst size must be at least 1, got %d", cfg.BurstSize)
+	}
+
+	return nil
+}
+
+// accountID - Returns the account-id of the account $i, empty for a single,
+// account stream to keep the original format.
+func (gen *generator) accountID(i int) string {
+	if gen.cfg.Accounts == 1 {
+		return ""
+	}
+
+	return fmt.Sprintf("account-%d", i+1)
+}
+
+// transaction - Returns the next transaction operation of the account,
+// the time moves seconds within a burst, or a random gap otherwise.
+func (gen *generator) transaction(id string, burst bool) *operation {
+	if burst {
+		gen.now = gen.now.Add(time.Duration(gen.rnd.Intn(10)+1) * time.Second)
+	} else {
+		gen.now = gen.now.Add(time.Duration(gen.rnd.ExpFloat64() * float64(gen.cfg.Gap)))
+	}
+
+	tsn := &account.Transaction{
+		Merchant: gen.merchant(),
+		Amount:   gen.amount(),
+	}
+	if prev := gen.previous[id]; prev != nil && gen.rnd.Float64() < gen.cfg.DoubledRate {
+		tsn.Merchant = prev.Merchant
+		tsn.Amount = prev.Amount
+	}
+	tsn.Time = gen.now.UTC().Format(timeFormat)
+	gen.previous[id] = tsn
+
+	return &operation{AccountID: id, Transaction: tsn}
+}
+
+// merchant - Returns a merchant name, following the popularity skew.
+func (gen *generator) merchant() string {
+	n := 0
+	if gen.zipf != nil {
+		n = int(gen.zipf.Uint64())
+	} else {
+		n = gen.rnd.Intn(gen.cfg.Merchants)
+	}
+
+	return fmt.Sprintf("Merchant %d", n+1)
+}
+
+// amount - Returns an amount, following the amounts distribution.
+func (gen *generator) amount() int {
+	low := gen.cfg.MinAmount
+	high := gen.cfg.MaxAmount
+	if gen.cfg.Amounts == Exponential {
+		mean := float64(high-low) / 4
+		return low + int(math.Min(gen.rnd.ExpFloat64()*mean, float64(high-low)))
+	}
+
+	return low + gen.rnd.Intn(high-low+1)
+}
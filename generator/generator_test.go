@@ -0,0 +1,126 @@
+// ********************************************************************
+// * generator_test.go                                                *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the generator.  *
+// *                                                                  *
+// * Usage: go test -v ./generator                                    *
+// ********************************************************************
+
+package generator
+
+import (
+	"authorizer/account"
+	"authorizer/executer"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// generate - Returns the generated lines for the config.
+func generate(t *testing.T, cfg *Config) []string {
+	out := &bytes.Buffer{}
+	if err := Generate(out, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+// Test same seed generates the same stream.
+func TestGenerateSeed(t *testing.T) {
+	cfg := DefaultConfig()
+	first := generate(t, cfg)
+	assert := assert.New(t)
+	assert.Equal(first, generate(t, cfg), "Expected same stream.")
+
+	cfg.Seed = 2
+	assert.NotEqual(first, generate(t, cfg), "Expected a different stream.")
+}
+
+// Test single account streams keep the original format.
+func TestGenerateSingleAccount(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Transactions = 10
+	lines := generate(t, cfg)
+	assert := assert.New(t)
+	assert.Len(lines, 11, "Expected account and transactions lines.")
+	assert.Equal(
+		`{"account":{"active-card":true,"available-limit":1000}}`,
+		lines[0],
+		"Expected account creation first.",
+	)
+	for _, line := range lines {
+		assert.NotContains(line, "account-id", "Expected no account-id.")
+	}
+}
+
+// Test multiple accounts streams.
+func TestGenerateAccounts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Accounts = 3
+	lines := generate(t, cfg)
+	assert := assert.New(t)
+	assert.Len(lines, 3+cfg.Transactions, "Expected accounts and transactions lines.")
+	assert.True(
+		strings.HasPrefix(lines[2], `{"account-id":"account-3","account":`),
+		"Expected accounts creation first.",
+	)
+}
+
+// Test bursts and repeated transactions get violations, when executed.
+func TestGenerateViolations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Transactions = 500
+	cfg.Limit = 1000000
+	exe := executer.Init()
+	found := map[string]bool{}
+	for _, line := range generate(t, cfg) {
+		out := exe.Exec(line)
+		for _, code := range []int{4, 5} {
+			if strings.Contains(out, account.Violations[code]) {
+				found[account.Violations[code]] = true
+			}
+		}
+	}
+
+	assert := assert.New(t)
+	assert.True(found[account.Violations[4]], "Expected doubled transactions.")
+	assert.True(found[account.Violations[5]], "Expected high frequency transactions.")
+}
+
+// Test amounts within the range for every distribution.
+func TestGenerateAmounts(t *testing.T) {
+	for _, dist := range []string{Uniform, Exponential} {
+		cfg := DefaultConfig()
+		cfg.Amounts = dist
+		cfg.MinAmount = 10
+		cfg.MaxAmount = 20
+		gen := &generator{cfg: cfg, rnd: rand.New(rand.NewSource(1))}
+		for i := 0; i < 1000; i++ {
+			amount := gen.amount()
+			if amount < 10 || amount > 20 {
+				t.Fatalf("Expected %s amount in [10, 20], got %d.", dist, amount)
+			}
+		}
+	}
+}
+
+// Test not valid configs.
+func TestGenerateNotValid(t *testing.T) {
+	cfgs := map[string]func(*Config){
+		"accounts":  func(cfg *Config) { cfg.Accounts = 0 },
+		"merchants": func(cfg *Config) { cfg.Merchants = 0 },
+		"amounts":   func(cfg *Config) { cfg.MinAmount = 10; cfg.MaxAmount = 5 },
+		"dist":      func(cfg *Config) { cfg.Amounts = "normal" },
+		"burst":     func(cfg *Config) { cfg.BurstSize = 0 },
+	}
+	for name, change := range cfgs {
+		cfg := DefaultConfig()
+		change(cfg)
+		assert.NotNil(t, Generate(&bytes.Buffer{}, cfg), "Expected error for "+name)
+	}
+}
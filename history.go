@@ -0,0 +1,277 @@
+// ********************************************************************
+// * history.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds declined transactions flag, JR                   *
+// *                                                                  *
+// * The "history" subcommand, replays an event log of operations,    *
+// * or reads a snapshot previously exported, and exports the         *
+// * accounts history filtered, as json lines or csv.                 *
+// *                                                                  *
+// * Usage:                                                           *
+// * $ authorizer history [-account-id ID -format csv] $EVENT_LOG     *
+// * $ authorizer history -snapshot [-outcome O] $SNAPSHOT            *
+// ********************************************************************
+
+package main
+
+import (
+	"authorizer/account"
+	"authorizer/executer"
+	"authorizer/executer/message"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyColumns - Header of the history csv export.
+var historyColumns = []string{"account-id", "id", "merchant", "amount", "time", "decision", "violations"}
+
+// historyRecord - represents a history entry exported, of an account-id,
+// with the violations by name. The json export is a snapshot to read,
+// again with -snapshot.
+type historyRecord struct {
+	AccountID  string   `json:"account-id"`
+	ID         string   `json:"id,omitempty"`
+	Merchant   string   `json:"merchant"`
+	Amount     int      `json:"amount"`
+	Time       string   `json:"time"`
+	Decision   string   `json:"decision"`
+	Violations []string `json:"violations"`
+}
+
+// history - Parses the history subcommand arguments and exports the,
+// history, returns the exit code.
+func history(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	snapshot := flags.Bool("snapshot", false,
+		"Reads a json history previously exported, instead of an event log.")
+	inputFormat := flags.String("input-format", message.NDJSON,
+		"Format of the event log operations: "+strings.Join(message.Formats, ", ")+".")
+	rulesFile := flags.String("rules", "",
+		"Json file with the rules the event log is replayed with.")
+	accountID := flags.String("account-id", "",
+		"Exports only the account-id history, all the accounts if not set.")
+	from := flags.String("from", "",
+		"Exports the transactions from the time (RFC 3339), included.")
+	to := flags.String("to", "",
+		"Exports the transactions up to the time (RFC 3339), included.")
+	merchant := flags.String("merchant", "",
+		"Exports only the merchant transactions.")
+	outcome := flags.String("outcome", "",
+		"Exports only the transactions with the decision.")
+	declined := flags.Bool("declined", false,
+		"Exports the declined transactions too.")
+	format := flags.String("format", "json",
+		"Format of the export: json or csv.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: authorizer history [-snapshot] [flags] $FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	filter := account.Filter{Merchant: *merchant, Outcome: *outcome, Declined: *declined}
+	var err error
+	if filter.From, err = historyTime(*from); err != nil {
+		fmt.Fprintln(os.Stderr, "history: -from:", err)
+		return 2
+	}
+	if filter.To, err = historyTime(*to); err != nil {
+		fmt.Fprintln(os.Stderr, "history: -to:", err)
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "history: unknown format %q\n", *format)
+		return 2
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "history:", err)
+		return 1
+	}
+	defer file.Close()
+
+	var records []historyRecord
+	if *snapshot {
+		records, err = readSnapshot(file, *accountID, filter)
+	} else {
+		records, err = replayHistory(file, *inputFormat, *rulesFile, *accountID, filter)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "history:", err)
+		return 1
+	}
+
+	if *format == "csv" {
+		err = writeHistoryCSV(out, records)
+	} else {
+		err = writeHistoryJSON(out, records)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "history:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// historyTime - Returns the time of a RFC 3339 value, zero if empty.
+func historyTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// replayHistory - Returns the history records of the accounts built by,
+// the operations of the event log, replayed with the rules of the file,
+// or the default ones, the outputs are discarded.
+func replayHistory(r io.Reader, format string, rulesFile string, id string, f account.Filter) ([]historyRecord, error) {
+	dec, err := message.NewDecoder(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	e := executer.Init()
+	if rulesFile != "" {
+		rules, err := loadRules(rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		e.SetRules(rules)
+	}
+	if err := e.Stream(context.Background(), dec, message.NewNDJSONEncoder(io.Discard)); err != nil {
+		return nil, err
+	}
+
+	records := []historyRecord{}
+	for _, acn := range e.Accounts() {
+		if id != "" && string(acn) != id {
+			continue
+		}
+		for entry := range e.History(acn, f) {
+			records = append(records, newHistoryRecord(string(acn), entry))
+		}
+	}
+
+	return records, nil
+}
+
+// readSnapshot - Returns the history records of a json export matching,
+// the account-id, if set, and the filter.
+func readSnapshot(r io.Reader, id string, f account.Filter) ([]historyRecord, error) {
+	records := []historyRecord{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		// Lines that are not records, as operations, are not a snapshot.
+		var record historyRecord
+		dec := json.NewDecoder(strings.NewReader(scanner.Text()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&record); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		entry, err := record.entry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if (id == "" || record.AccountID == id) && f.Match(entry) {
+			records = append(records, record)
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// newHistoryRecord - Returns the record exported of an account-id entry.
+func newHistoryRecord(id string, e account.Entry) historyRecord {
+	record := historyRecord{
+		AccountID:  id,
+		ID:         e.ID,
+		Merchant:   e.Merchant,
+		Amount:     e.Amount,
+		Time:       e.Time,
+		Decision:   e.Decision,
+		Violations: []string{},
+	}
+	for _, code := range e.Violations {
+		record.Violations = append(record.Violations, account.Violations[code])
+	}
+
+	return record
+}
+
+// entry - Returns the history entry of the record, to be filtered.
+func (record historyRecord) entry() (account.Entry, error) {
+	e := account.Entry{
+		Transaction: account.Transaction{
+			ID:       record.ID,
+			Merchant: record.Merchant,
+			Amount:   record.Amount,
+			Time:     record.Time,
+		},
+		Decision:   record.Decision,
+		Violations: []int{},
+	}
+	for _, name := range record.Violations {
+		code, ok := account.Code(name)
+		if !ok {
+			return e, fmt.Errorf("unknown violation %q", name)
+		}
+		e.Violations = append(e.Violations, code)
+	}
+
+	return e, nil
+}
+
+// writeHistoryJSON - Writes the records as json lines.
+func writeHistoryJSON(w io.Writer, records []historyRecord) error {
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeHistoryCSV - Writes the records as csv with a header, the,
+// violations are joined by "|".
+func writeHistoryCSV(w io.Writer, records []historyRecord) error {
+	writer := csv.NewWriter(w)
+	writer.Write(historyColumns)
+	for _, record := range records {
+		writer.Write([]string{
+			record.AccountID,
+			record.ID,
+			record.Merchant,
+			strconv.Itoa(record.Amount),
+			record.Time,
+			record.Decision,
+			strings.Join(record.Violations, "|"),
+		})
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,131 @@
+// ********************************************************************
+// * metrics.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Moves accounts by card state changes, JR              *
+// *                                                                  *
+// * Package responsible of the prometheus metrics of the             *
+// * authorizations, operations by type, decisions, violations,       *
+// * processing latency, transactions amounts and accounts, to be     *
+// * exposed on a /metrics http endpoint.                             *
+// *                                                                  *
+// * Usage:                                                           *
+// * m := metrics.New()                                               *
+// * e.SetMetrics(m)                                                  *
+// * http.Handle("/metrics", m.Handler())                             *
+// ********************************************************************
+
+package metrics
+
+import (
+	"authorizer/account"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Namespace of the metrics names.
+const namespace = "authorizer"
+
+// Metrics - Holds the collectors, in their own registry. It is safe for,
+// concurrent use, so several executers can share it.
+type Metrics struct {
+	registry   *prometheus.Registry
+	operations *prometheus.CounterVec
+	decisions  *prometheus.CounterVec
+	violations *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	amounts    prometheus.Histogram
+	accounts   *prometheus.GaugeVec
+}
+
+// New - Returns the metrics registered, every violation code and decision,
+// starts at 0 so they are exposed before they are found.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Operations executed, by type.",
+		}, []string{"operation"}),
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "decisions_total",
+			Help:      "Transactions decisions.",
+		}, []string{"decision"}),
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "violations_total",
+			Help:      "Violations found, by code and name.",
+		}, []string{"code", "violation"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Operations processing latency, by type.",
+			Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 12),
+		}, []string{"operation"}),
+		amounts: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "transaction_amount",
+			Help:      "Transactions amounts.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+		}),
+		accounts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "accounts",
+			Help:      "Initialized accounts, by usable card.",
+		}, []string{"active"}),
+	}
+	m.registry.MustRegister(m.operations, m.decisions, m.violations,
+		m.latency, m.amounts, m.accounts)
+
+	for code, name := range account.Violations {
+		m.violations.WithLabelValues(strconv.Itoa(code), name)
+	}
+	for _, decision := range []string{account.Approved, account.ApprovedWithFlags, account.Declined} {
+		m.decisions.WithLabelValues(decision)
+	}
+	for _, active := range []bool{true, false} {
+		m.accounts.WithLabelValues(strconv.FormatBool(active))
+	}
+
+	return m
+}
+
+// Handler - Returns the http handler exposing the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Operation - Counts an operation of the type, with its latency,
+// the decision and violations found.
+func (m *Metrics) Operation(operation string, latency time.Duration, decision string, violations []int) {
+	m.operations.WithLabelValues(operation).Inc()
+	m.latency.WithLabelValues(operation).Observe(latency.Seconds())
+	if decision != "" {
+		m.decisions.WithLabelValues(decision).Inc()
+	}
+	for _, v := range violations {
+		m.violations.WithLabelValues(strconv.Itoa(v), account.Violations[v]).Inc()
+	}
+}
+
+// Amount - Observes a transaction amount.
+func (m *Metrics) Amount(amount int) {
+	m.amounts.Observe(float64(amount))
+}
+
+// AccountCreated - Counts a new account, by its card usable or not.
+func (m *Metrics) AccountCreated(active bool) {
+	m.accounts.WithLabelValues(strconv.FormatBool(active)).Inc()
+}
+
+// AccountChanged - Moves an account whose card changed from usable to,
+// not usable or the other way, to the label of $active.
+func (m *Metrics) AccountChanged(active bool) {
+	m.accounts.WithLabelValues(strconv.FormatBool(!active)).Dec()
+	m.accounts.WithLabelValues(strconv.FormatBool(active)).Inc()
+}
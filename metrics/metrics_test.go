@@ -0,0 +1,87 @@
+// ********************************************************************
+// * metrics_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// * 2026-10-19 Adds accounts moved by card changes test, JR          *
+// *                                                                  *
+// * This file contains all unit testing related with the metrics,    *
+// * scraped from the http handler.                                   *
+// *                                                                  *
+// * Usage: go test -v ./metrics                                      *
+// ********************************************************************
+
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// scrape - Returns the metrics exposition served by the handler.
+func scrape(t *testing.T, m *Metrics) string {
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+// Test all violations and decisions are exposed before they are found.
+func TestMetricsInitialized(t *testing.T) {
+	body := scrape(t, New())
+	assert := assert.New(t)
+	assert.Contains(body, `authorizer_violations_total{code="0",violation="account-not-initialized"} 0`)
+	assert.Contains(body, `authorizer_violations_total{code="11",violation="invalid-amount"} 0`)
+	assert.Contains(body, `authorizer_decisions_total{decision="approved-with-flags"} 0`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 0`)
+}
+
+// Test operations, decisions, violations, amounts and accounts counted.
+func TestMetricsOperations(t *testing.T) {
+	m := New()
+	m.AccountCreated(true)
+	m.Operation("account", time.Millisecond, "", []int{})
+	m.Amount(20)
+	m.Operation("transaction", time.Millisecond, "approved", []int{})
+	m.Amount(200)
+	m.Operation("transaction", time.Millisecond, "declined", []int{3, 6})
+
+	body := scrape(t, m)
+	assert := assert.New(t)
+	assert.Contains(body, `authorizer_operations_total{operation="account"} 1`)
+	assert.Contains(body, `authorizer_operations_total{operation="transaction"} 2`)
+	assert.Contains(body, `authorizer_decisions_total{decision="approved"} 1`)
+	assert.Contains(body, `authorizer_decisions_total{decision="declined"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="3",violation="insufficient-limit"} 1`)
+	assert.Contains(body, `authorizer_violations_total{code="6",violation="blocked-merchant"} 1`)
+	assert.Contains(body, `authorizer_operation_duration_seconds_count{operation="transaction"} 2`)
+	assert.Contains(body, `authorizer_transaction_amount_sum 220`)
+	assert.Contains(body, `authorizer_transaction_amount_count 2`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 1`)
+}
+
+// Test accounts moved between labels by their card changes.
+func TestMetricsAccountChanged(t *testing.T) {
+	m := New()
+	m.AccountCreated(true)
+	m.AccountCreated(false)
+	m.AccountChanged(false)
+
+	body := scrape(t, m)
+	assert := assert.New(t)
+	assert.Contains(body, `authorizer_accounts{active="false"} 2`)
+	assert.Contains(body, `authorizer_accounts{active="true"} 0`)
+}
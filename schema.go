@@ -0,0 +1,52 @@
+// ********************************************************************
+// * schema.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * The "schema" subcommand, writes the published json schema of an  *
+// * operation, or the output, in a protocol version, to validate     *
+// * the messages outside the authorizer.                             *
+// *                                                                  *
+// * Usage:                                                           *
+// * $ authorizer schema [-version V] $NAME                           *
+// ********************************************************************
+
+package main
+
+import (
+	"authorizer/executer/message"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// schema - Parses the schema subcommand arguments and writes the schema,
+// returns the exit code.
+func schema(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	version := flags.Int("version", message.Versions[len(message.Versions)-1],
+		"Protocol version of the schema.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: authorizer schema [-version V] $NAME\nNames: %s\n",
+			strings.Join(message.Schemas, ", "))
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := message.Schema(*version, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "schema:", err)
+		return 1
+	}
+	out.Write(data)
+
+	return 0
+}
@@ -0,0 +1,108 @@
+// ********************************************************************
+// * tracing.go                                                       *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * Package responsible of the opentelemetry tracer provider of the  *
+// * executer spans, exported as json lines to a local file, to be    *
+// * inspected offline, and/or to an OTLP http collector.             *
+// *                                                                  *
+// * Usage:                                                           *
+// * tp, err := tracing.New(ctx, "traces.json", "http://host:4318")   *
+// * e.SetTracerProvider(tp)                                          *
+// * defer tp.Shutdown(ctx)                                           *
+// ********************************************************************
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"net/url"
+	"os"
+)
+
+// Service name the spans are exported with.
+const service = "authorizer"
+
+// Provider - Tracer provider of the spans, exporting them in batches,
+// Shutdown must be called to export the pending spans and close the file.
+type Provider struct {
+	*sdktrace.TracerProvider
+	file *os.File
+}
+
+// New - Returns a tracer provider exporting the spans to the $file, as,
+// one json object by line, and to the OTLP http collector at the $endpoint,
+// url (e.g. http://localhost:4318), any of them can be empty.
+func New(ctx context.Context, file string, endpoint string) (*Provider, error) {
+	p := &Provider{}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	}
+
+	if endpoint != "" {
+		exporter, err := otlp(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	if file != "" {
+		var err error
+		if p.file, err = os.Create(file); err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(p.file))
+		if err != nil {
+			p.file.Close()
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	p.TracerProvider = sdktrace.NewTracerProvider(options...)
+	return p, nil
+}
+
+// Shutdown - Exports the pending spans, stops the provider and closes,
+// the file.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.TracerProvider.Shutdown(ctx)
+	if p.file != nil {
+		if cerr := p.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// otlp - Returns the OTLP http exporter of the $endpoint url, plain http,
+// endpoints are sent without tls.
+func otlp(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		options = append(options, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("trace endpoint %q: expected an http or https url", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	return otlptracehttp.New(ctx, options...)
+}
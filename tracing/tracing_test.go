@@ -0,0 +1,91 @@
+// ********************************************************************
+// * tracing_test.go                                                  *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * This file contains all unit testing related with the spans       *
+// * exported to a local file, and to an OTLP http test server.       *
+// *                                                                  *
+// * Usage: go test -v ./tracing                                      *
+// ********************************************************************
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// tspan - Starts and ends a span from the provider.
+func tspan(p *Provider) {
+	_, span := p.Tracer("test").Start(context.Background(), "exec")
+	span.SetAttributes(attribute.String("account.id", "a1"), attribute.IntSlice("violation.codes", []int{3}))
+	span.End()
+}
+
+// Test spans exported to the file as json lines.
+func TestTracingFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+	p, err := New(context.Background(), file, "")
+	assert := assert.New(t)
+	assert.NoError(err)
+	tspan(p)
+	tspan(p)
+	assert.NoError(p.Shutdown(context.Background()))
+
+	f, err := os.Open(file)
+	assert.NoError(err)
+	defer f.Close()
+	spans := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		span := struct {
+			Name       string
+			Attributes []struct {
+				Key   string
+				Value struct{ Value interface{} }
+			}
+		}{}
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &span))
+		assert.Equal("exec", span.Name)
+		assert.Equal("account.id", span.Attributes[0].Key)
+		assert.Equal("a1", span.Attributes[0].Value.Value)
+		assert.Equal([]interface{}{3.0}, span.Attributes[1].Value.Value)
+		spans++
+	}
+	assert.Equal(2, spans, "Expected a line by span.")
+}
+
+// Test spans exported to the OTLP http endpoint.
+func TestTracingOTLP(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			atomic.AddInt32(&requests, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p, err := New(context.Background(), "", server.URL)
+	assert := assert.New(t)
+	assert.NoError(err)
+	tspan(p)
+	assert.NoError(p.Shutdown(context.Background()))
+	assert.Equal(int32(1), atomic.LoadInt32(&requests), "Expected the spans sent.")
+}
+
+// Test endpoints must be http urls.
+func TestTracingEndpoint(t *testing.T) {
+	_, err := New(context.Background(), "", "localhost:4318")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+// ********************************************************************
+// * verify.go                                                        *
+// *                                                                  *
+// * 2026-10-19 First Version, JR                                     *
+// *                                                                  *
+// * The "verify-audit" subcommand, checks the hash chain of an       *
+// * audit log, with its rotated files, to detect tampering.          *
+// *                                                                  *
+// * Usage:                                                           *
+// * $ authorizer verify-audit $AUDIT_FILE                            *
+// ********************************************************************
+
+package main
+
+import (
+	"authorizer/audit"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// verifyAudit - Parses the verify-audit subcommand arguments and checks,
+// the audit log, returns the exit code.
+func verifyAudit(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: authorizer verify-audit $AUDIT_FILE")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	files := audit.Files(flags.Arg(0))
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "verify-audit: no audit files found for", flags.Arg(0))
+		return 1
+	}
+	n, err := audit.VerifyFiles(files...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verify-audit:", err)
+		return 1
+	}
+
+	fmt.Fprintf(out, "%d records verified in %d files\n", n, len(files))
+	return 0
+}